@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"md2s/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 現在の試合の特殊ルールと選択できる特殊ルールの一覧を取得
+func GetArenaHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, services.GetArena())
+}
+
+// 次の試合の特殊ルールを指定
+// modifiers を省略 (null) するとルール設定に戻し、空の配列の場合は特殊ルールなしにする
+func SetNextArenaHandler(c *gin.Context) {
+	var input struct {
+		Modifiers []string `json:"modifiers"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if err := services.SetNextArena(input.Modifiers); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, services.GetArena())
+}
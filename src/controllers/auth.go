@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"md2s/services"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin ADMIN_TOKEN と一致するBearerトークンを要求する
+// ADMIN_TOKEN が未設定の場合は全て拒否する
+func RequireAdmin(c *gin.Context) {
+	if !isAdmin(c) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	c.Next()
+}
+
+// リクエストが管理者のBearerトークンを持っているか
+func isAdmin(c *gin.Context) bool {
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		return false
+	}
+	token := bearerToken(c.GetHeader("Authorization"))
+	return subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
+
+// RequireUser パスの userId のユーザートークンか管理者のBearerトークンを要求する
+func RequireUser(c *gin.Context) {
+	if !isAdmin(c) && services.VerifyUserToken(c.Param("userId"), bearerToken(c.GetHeader("Authorization"))) != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": services.ErrUserUnauthenticated.Error()})
+		return
+	}
+	c.Next()
+}
+
+// 接続時にクエリの userId で参加するユーザー (ユーザートークンを Authorization ヘッダーで検証する)
+// 検証に失敗した場合はレスポンスを返して false
+func connectingUser(c *gin.Context) (string, bool) {
+	userID := c.Query("userId")
+	if userID == "" {
+		return "", true
+	}
+	if err := services.VerifyUserToken(userID, bearerToken(c.GetHeader("Authorization"))); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return userID, true
+}
+
+// "Bearer xxx" からトークンを取り出す
+func bearerToken(header string) string {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// デバイスのリクエストの署名を検証 (失敗した場合はレスポンスを返して false)
+//
+//	X-Device-Timestamp: UNIXミリ秒
+//	X-Device-Nonce:     リクエストごとに一意な値
+//	X-Device-Signature: hex(HMAC-SHA256(secret, timestamp + "\n" + nonce + "\n" + body))
+func authenticateDeviceRequest(c *gin.Context, deviceID string, body []byte) bool {
+	if !services.DeviceAuthRequired(deviceID) {
+		return true
+	}
+
+	err := services.VerifyDeviceSignature(
+		deviceID,
+		c.GetHeader("X-Device-Timestamp"),
+		c.GetHeader("X-Device-Nonce"),
+		c.GetHeader("X-Device-Signature"),
+		body,
+	)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// デバイスにシークレットを発行
+func IssueDeviceCredentialsHandler(c *gin.Context) {
+	deviceID := c.Param("deviceId")
+
+	secret, err := services.IssueDeviceSecret(deviceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// シークレットはこのレスポンスでしか返さない
+	c.JSON(http.StatusCreated, gin.H{
+		"deviceId": deviceID,
+		"secret":   secret,
+		"token":    services.DeviceToken(deviceID, secret),
+	})
+}
+
+// デバイスのシークレットを無効にする
+func RevokeDeviceCredentialsHandler(c *gin.Context) {
+	if err := services.RevokeDeviceSecret(c.Param("deviceId")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// デバイスの認証失敗の記録を取得
+func GetDeviceAuthStatsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, services.GetDeviceAuthStats())
+}
+
+// ユーザーのトークンを発行 (ユーザーを確認したサービスが管理者として呼ぶ)
+func IssueUserTokenHandler(c *gin.Context) {
+	userID := c.Param("userId")
+
+	token, err := services.UserToken(userID)
+	switch err {
+	case nil:
+		c.JSON(http.StatusCreated, gin.H{"userId": userID, "token": token})
+	case services.ErrInvalidUser:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrUserTokenDisabled:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"md2s/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 選択できるクラスの一覧を取得
+func ListClassesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, services.ListClasses())
+}
+
+// ユーザーのロードアウトを取得
+func GetLoadoutHandler(c *gin.Context) {
+	loadout, err := services.GetLoadout(c.Param("userId"))
+	if err == services.ErrInvalidUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loadout)
+}
+
+// ユーザーのロードアウトを保存
+func SaveLoadoutHandler(c *gin.Context) {
+	var input struct {
+		Class string `json:"class"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	loadout, err := services.SaveLoadout(c.Param("userId"), input.Class)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, loadout)
+	case services.ErrInvalidUser, services.ErrUnknownClass:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
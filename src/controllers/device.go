@@ -1,35 +1,140 @@
 package controllers
 
 import (
+	"encoding/json"
+	"md2s/dto"
 	"md2s/services"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-//  デバイスからの入力を処理
+// デバイスからの入力を処理
 func ProcessDeviceInputHandler(c *gin.Context) {
-	var input struct {
-		DeviceID string `json:"deviceId"`
-		Action   string `json:"action"`
-		State   string `json:"state"`
-	}
+	// 再送時の重複適用を防ぐため seq か Idempotency-Key ヘッダーを指定できる
+	var input dto.DeviceInput
 
-	if err := c.ShouldBindJSON(&input); err != nil {
+	// 署名の検証にリクエストボディそのものが必要
+	body, err := c.GetRawData()
+	if err != nil || json.Unmarshal(body, &input) != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
 		return
 	}
 
-	if err := services.HttpProcessInputFromDevice(input.DeviceID, input.Action, input.State); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !authenticateDeviceRequest(c, input.DeviceID, body) {
+		return
+	}
+
+	if err := services.AllowDeviceInput(input.DeviceID, c.ClientIP()); err != nil {
+		abortDeviceInput(c, err)
+		return
+	}
+
+	key := services.InputKey{Idempotency: c.GetHeader("Idempotency-Key"), Seq: input.Seq, BootID: input.BootID}
+
+	duplicate, err := services.ProcessDeviceInputOnce(input.DeviceID, key, func() error {
+		return services.HttpProcessInputFromDevice(input)
+	})
+	if duplicate {
+		// 再送の場合は最初の結果をそのまま返す
+		c.Header("Idempotent-Replayed", "true")
+	}
+
+	if err == services.ErrStaleInput {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "seq": key.String()})
+		return
+	}
+
+	if err == services.ErrDeviceSuspended {
+		abortDeviceInput(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"error": "Input processed successfully"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "seq": key.String()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"error": "Input processed successfully", "seq": key.String()})
+}
+
+// レート制限・停止中・ペアリングされていないデバイスの入力を拒否する
+func abortDeviceInput(c *gin.Context, err error) {
+	switch err {
+	case services.ErrRateLimited:
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case services.ErrInvalidDevice:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	}
 }
 
-//  現在のゲーム状態を取得
+// 現在のゲーム状態を取得
 func GetGameStateHandler(c *gin.Context) {
 	gameState := services.GetGameState()
 	c.JSON(http.StatusOK, gameState)
 }
+
+// 時刻同期のリクエスト (HTTPのみのデバイス用)
+// デバイスは t0 を送り、応答の t1, t2 と受信時刻 t3 を /device/clock/result に報告する
+func ClockSyncHandler(c *gin.Context) {
+	receivedAt := time.Now()
+
+	var input struct {
+		DeviceID string `json:"deviceId"`
+		T0       int64  `json:"t0"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	c.JSON(http.StatusOK, services.ClockSync(input.T0, receivedAt))
+}
+
+// 時刻同期の結果を記録 (入力と同じく署名で認証する)
+func ClockSyncResultHandler(c *gin.Context) {
+	var input dto.ClockSyncResult
+
+	// 署名の検証にリクエストボディそのものが必要
+	body, err := c.GetRawData()
+	if err != nil || json.Unmarshal(body, &input) != nil || input.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if !authenticateDeviceRequest(c, input.DeviceID, body) {
+		return
+	}
+
+	offset, rtt, err := services.RecordClockSync(input)
+	if err == services.ErrInvalidDevice {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"offsetMs": offset.Milliseconds(), "rttMs": rtt.Milliseconds()})
+}
+
+// HTTPのみのデバイスが溜まっているコマンド (振動・LED・音) を受け取る
+// GET /device/commands?deviceId=xxx (署名が必要なデバイスはクエリ文字列をペイロードとして署名する)
+func PollDeviceCommandsHandler(c *gin.Context) {
+	deviceID := c.Query("deviceId")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "deviceId is required"})
+		return
+	}
+
+	if !authenticateDeviceRequest(c, deviceID, []byte(c.Request.URL.RawQuery)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, services.PollDeviceCommands(deviceID))
+}
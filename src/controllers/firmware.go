@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"md2s/dto"
+	"md2s/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ファームウェアをアップロード (multipart: file, deviceType, version, notes)
+func UploadFirmwareHandler(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	firmware, err := services.UploadFirmware(c.PostForm("deviceType"), c.PostForm("version"), c.PostForm("notes"), file)
+	if err == services.ErrInvalidFirmware || err == services.ErrInvalidFirmwareVersion {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == services.ErrFirmwareExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, firmware)
+}
+
+// 登録されているファームウェアの一覧を取得
+func ListFirmwaresHandler(c *gin.Context) {
+	firmwares, err := services.ListFirmwares()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, firmwares)
+}
+
+// ファームウェアを削除
+func DeleteFirmwareHandler(c *gin.Context) {
+	firmwareID, ok := firmwareIDParam(c)
+	if !ok {
+		return
+	}
+
+	err := services.DeleteFirmware(firmwareID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "firmware not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ファームウェアの配信割合の一覧を取得
+func ListFirmwareRolloutsHandler(c *gin.Context) {
+	firmwareID, ok := firmwareIDParam(c)
+	if !ok {
+		return
+	}
+
+	rollouts, err := services.ListFirmwareRollouts(firmwareID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rollouts)
+}
+
+// デバイスグループへの配信割合を設定
+func SetFirmwareRolloutHandler(c *gin.Context) {
+	firmwareID, ok := firmwareIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Group      string `json:"group"`
+		Percentage int    `json:"percentage"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	rollout, err := services.SetFirmwareRollout(firmwareID, input.Group, input.Percentage)
+	if err == services.ErrInvalidPercentage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "firmware not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rollout)
+}
+
+// デバイスが現在のバージョンを報告して更新を確認する
+// GET /device/firmware?deviceId=xxx&version=1.2.0&type=xxx (type は未登録のデバイスのみ使う)
+// 署名が必要なデバイスはクエリ文字列をペイロードとして署名する
+func CheckFirmwareUpdateHandler(c *gin.Context) {
+	deviceID := c.Query("deviceId")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "deviceId is required"})
+		return
+	}
+
+	if !authenticateDeviceRequest(c, deviceID, []byte(c.Request.URL.RawQuery)) {
+		return
+	}
+
+	firmware, err := services.CheckFirmwareUpdate(deviceID, c.Query("version"), c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if firmware == nil {
+		c.JSON(http.StatusOK, gin.H{"update": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"update":   true,
+		"firmware": firmware,
+		"url":      fmt.Sprintf("/firmware/%d/download", firmware.ID),
+	})
+}
+
+// ファームウェアのイメージをダウンロード
+func DownloadFirmwareHandler(c *gin.Context) {
+	firmwareID, ok := firmwareIDParam(c)
+	if !ok {
+		return
+	}
+
+	firmware, err := services.GetFirmware(firmwareID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "firmware not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// デバイスは書き込み前にチェックサムを検証する
+	c.Header("X-Firmware-Version", firmware.Version)
+	c.Header("X-Firmware-Checksum", "sha256="+firmware.Checksum)
+	c.FileAttachment(firmware.FilePath, fmt.Sprintf("%s-%s.bin", firmware.DeviceType, firmware.Version))
+}
+
+// デバイスからファームウェア更新の結果を受け取る
+func ReportFirmwareUpdateHandler(c *gin.Context) {
+	var input dto.FirmwareUpdateReport
+
+	body, err := c.GetRawData()
+	if err != nil || json.Unmarshal(body, &input) != nil || input.DeviceID == "" || input.Version == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if !authenticateDeviceRequest(c, input.DeviceID, body) {
+		return
+	}
+
+	if err := services.ReportFirmwareUpdate(input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// パスパラメーターのファームウェアIDを取得 (不正な場合はレスポンスを返して false)
+func firmwareIDParam(c *gin.Context) (int, bool) {
+	firmwareID, err := strconv.Atoi(c.Param("firmwareId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid firmware ID"})
+		return 0, false
+	}
+	return firmwareID, true
+}
@@ -1,9 +1,13 @@
 package controllers
 
 import (
+	"encoding/json"
 	"log"
+	"md2s/dto"
+	"md2s/models"
 	"md2s/services"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -16,19 +20,46 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// WebSocketにアップグレードする
+// クライアントはサブプロトコルで json / json.envelope / msgpack / protobuf を選択できる
+// (複数提示された場合はクライアントの提示順を優先する)
+func upgradeWebSocket(c *gin.Context) (*websocket.Conn, error) {
+	var header http.Header
+	if protocol := services.SelectSubprotocol(websocket.Subprotocols(c.Request)); protocol != "" {
+		header = http.Header{"Sec-Websocket-Protocol": {protocol}}
+	}
+	return upgrader.Upgrade(c.Writer, c.Request, header)
+}
+
 // HandleWebSocket はWebSocket接続を処理します
 func HandleWebSocket(c *gin.Context) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	// クエリパラメータでデバイスIDを取得
+	deviceID := c.Query("deviceId")
+	playerId := c.Query("playerId")
+
+	// デバイスはBearerトークン (Authorization ヘッダー) で認証する
+	// (クエリのトークンはログやリファラーに残るため受け付けない)
+	if deviceID != "" && services.DeviceAuthRequired(deviceID) {
+		token := bearerToken(c.GetHeader("Authorization"))
+		if err := services.VerifyDeviceToken(deviceID, token); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// プレイヤーとして userId で参加する場合はユーザートークンで認証する
+	userID, ok := connectingUser(c)
+	if !ok {
+		return
+	}
+
+	conn, err := upgradeWebSocket(c)
 	if err != nil {
 		log.Printf("Failed to upgrade connection: %v", err)
 		return
 	}
 	defer conn.Close()
 
-	// クエリパラメータでデバイスIDを取得
-	deviceID := c.Query("deviceId")
-	playerId := c.Query("playerId")
-
 	// もしどっちもない場合はエラー
 	if deviceID == "" && playerId == "" {
 		log.Printf("No device ID or player ID provided")
@@ -36,15 +67,16 @@ func HandleWebSocket(c *gin.Context) {
 	}
 
 	// デバイスを登録
+	// 登録したデバイスにはゲームのイベントに応じて {"type": "command", "vibrate", "led", "sound", ...} を送る
 
+	var device *services.Device
 	if deviceID != "" {
-	services.RegisterDevice(deviceID, conn)
-	}else{
-	// プレイヤーを登録
-	services.RegisterPlayer(playerId, conn)
+		device = services.RegisterDevice(deviceID, conn)
+	} else {
+		// プレイヤーを登録
+		services.RegisterPlayer(playerId, userID, conn)
 	}
 
-
 	// メッセージの受信
 	for {
 		_, message, err := conn.ReadMessage()
@@ -52,11 +84,97 @@ func HandleWebSocket(c *gin.Context) {
 			log.Printf("Error reading message from device %s: %v", deviceID, err)
 			break
 		}
+		receivedAt := time.Now()
+
+		// 時刻同期・テレメトリのメッセージ
+		if device != nil && handleDeviceMessage(device, message, receivedAt) {
+			continue
+		}
 
-		// 入力を処理
-		services.ProcessInputFromDevice(deviceID, message)
+		// 入力を処理 (レート制限を超えた入力は破棄する)
+		// 処理結果は {"type": "result", "message" または "error": ...} で返す
+		if device == nil {
+			continue
+		}
+		if err := services.AllowDeviceInput(deviceID, c.ClientIP()); err != nil {
+			continue
+		}
+		reply := gin.H{"type": "result", "message": "Input processed successfully"}
+		if err := services.ProcessInputFromDevice(deviceID, message); err != nil {
+			reply = gin.H{"type": "result", "error": err.Error()}
+		}
+		if err := device.WriteJSON(reply); err != nil {
+			log.Printf("Error sending input result to device %s: %v", deviceID, err)
+		}
 	}
 
 	// デバイスの登録解除
 	services.UnregisterDevice(deviceID)
 }
+
+// デバイスからの入力以外のメッセージを処理 (入力であれば false)
+//
+//	{"type": "clockSync", "t0": ...}                              → {"type": "clockSync", "t0", "t1", "t2"}
+//	{"type": "clockSyncResult", "t0": ..., "t1", "t2", "t3"}  → {"type": "clockSyncResult", "offsetMs", "rttMs"}
+//	{"type": "telemetry", "batteryLevel": ..., "rssi", ...}
+//	{"type": "imu", "state": ..., "samples": [{"t", "ax", "ay", "az", "gx", "gy", "gz"}, ...]}
+//	                                                          → {"type": "gesture", "action", "confidence", "result"}
+func handleDeviceMessage(device *services.Device, message []byte, receivedAt time.Time) bool {
+	var msg struct {
+		Type string `json:"type"`
+		dto.ClockSyncResult
+	}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return false
+	}
+
+	switch msg.Type {
+	case "clockSync":
+		reply := services.ClockSync(msg.T0, receivedAt)
+		reply.Type = "clockSync"
+		if err := device.WriteJSON(reply); err != nil {
+			log.Printf("Error sending clock sync to device %s: %v", device.ID, err)
+		}
+		return true
+	case "clockSyncResult":
+		msg.DeviceID = device.ID
+		offset, rtt, err := services.RecordClockSync(msg.ClockSyncResult)
+		reply := gin.H{"type": "clockSyncResult", "offsetMs": offset.Milliseconds(), "rttMs": rtt.Milliseconds()}
+		if err != nil {
+			reply = gin.H{"type": "clockSyncResult", "error": err.Error()}
+		}
+		if err := device.WriteJSON(reply); err != nil {
+			log.Printf("Error sending clock sync result to device %s: %v", device.ID, err)
+		}
+		return true
+	case "telemetry":
+		var telemetry dto.DeviceTelemetry
+		if err := json.Unmarshal(message, &telemetry); err != nil {
+			log.Printf("Invalid telemetry from device %s: %v", device.ID, err)
+			return true
+		}
+		telemetry.DeviceID = device.ID
+		if err := services.RecordDeviceTelemetry(telemetry); err != nil {
+			log.Printf("Failed to record telemetry from device %s: %v", device.ID, err)
+		}
+		return true
+	case "imu":
+		var batch struct {
+			State   string             `json:"state"`
+			Samples []models.IMUSample `json:"samples"`
+		}
+		if err := json.Unmarshal(message, &batch); err != nil {
+			log.Printf("Invalid IMU samples from device %s: %v", device.ID, err)
+			return true
+		}
+		// 認識したジェスチャーは入力として処理し、結果をデバイスに返す
+		for _, result := range services.ProcessIMUSamples(device.ID, batch.State, batch.Samples) {
+			reply := gin.H{"type": "gesture", "action": result.Action, "confidence": result.Confidence, "result": result.Result}
+			if err := device.WriteJSON(reply); err != nil {
+				log.Printf("Error sending gesture result to device %s: %v", device.ID, err)
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"md2s/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// デバイスのジェスチャーの記録を開始
+func StartGestureRecordingHandler(c *gin.Context) {
+	var input struct {
+		DeviceID string `json:"deviceId"`
+		Label    string `json:"label"`
+		UserID   string `json:"userId"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	recording, err := services.StartGestureRecording(input.DeviceID, input.Label, input.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recording)
+}
+
+// デバイスのジェスチャーの記録を終了
+func StopGestureRecordingHandler(c *gin.Context) {
+	recording, exists := services.StopGestureRecording(c.Param("deviceId"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "device is not recording"})
+		return
+	}
+
+	c.JSON(http.StatusOK, recording)
+}
+
+// 記録中のデバイスの一覧を取得
+func ListGestureRecordingsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, services.ListGestureRecordings())
+}
+
+// ジェスチャーのサンプルを取得 (?label=&deviceId= で絞り込み)
+func ListGestureSamplesHandler(c *gin.Context) {
+	samples, err := services.ListGestureSamples(c.Query("label"), c.Query("deviceId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, samples)
+}
+
+// サンプルのラベルを変更
+func LabelGestureSampleHandler(c *gin.Context) {
+	sampleID, ok := gestureSampleIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Label string `json:"label"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	err := services.LabelGestureSample(sampleID, input.Label)
+	if err == services.ErrInvalidLabel {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == services.ErrGestureSampleNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// サンプルを削除
+func DeleteGestureSampleHandler(c *gin.Context) {
+	sampleID, ok := gestureSampleIDParam(c)
+	if !ok {
+		return
+	}
+
+	err := services.DeleteGestureSample(sampleID)
+	if err == services.ErrGestureSampleNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// サンプルを書き出す (?format=csv|jsonl&label=&deviceId=)
+func ExportGestureSamplesHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	contentType := map[string]string{"csv": "text/csv", "jsonl": "application/x-ndjson"}[format]
+	if contentType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrUnknownExportFormat.Error()})
+		return
+	}
+
+	samples, err := services.ListGestureSamples(c.Query("label"), c.Query("deviceId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 書き出し始めた後はレスポンスを変えられないので、失敗した場合はログに残すだけにする
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="gesture_samples.%s"`, format))
+	if err := services.WriteGestureSamples(c.Writer, format, samples); err != nil {
+		log.Printf("Failed to export gesture samples: %v", err)
+	}
+}
+
+// パスパラメーターのサンプルIDを取得 (不正な場合はレスポンスを返して false)
+func gestureSampleIDParam(c *gin.Context) (int, bool) {
+	sampleID, err := strconv.Atoi(c.Param("sampleId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sample ID"})
+		return 0, false
+	}
+	return sampleID, true
+}
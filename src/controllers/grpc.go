@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"md2s/dto"
+	"md2s/models"
+	"md2s/pb"
+	"md2s/services"
+	"net"
+	"os"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ストリームごとの送信バッファ (溢れた場合は古いクライアントとみなして切断)
+const grpcSendBuffer = 64
+
+var errSlowConsumer = status.Error(codes.ResourceExhausted, "client is not reading fast enough")
+
+type gameServer struct {
+	pb.UnimplementedGameServiceServer
+}
+
+// StartGRPCServer GRPC_ADDR が設定されている場合、gRPCサーバーを開始
+func StartGRPCServer() {
+	addr := os.Getenv("GRPC_ADDR")
+	if addr == "" {
+		return
+	}
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Printf("Failed to start gRPC listener: %v", err)
+		return
+	}
+
+	server := grpc.NewServer(grpc.StreamInterceptor(recoverStream))
+	pb.RegisterGameServiceServer(server, &gameServer{})
+
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+	log.Printf("gRPC server listening on %s", addr)
+}
+
+// ハンドラー内のpanicでプロセス全体が落ちないようにする
+func recoverStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in %s: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Error(codes.Internal, fmt.Sprint(r))
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// Play 入力を受け取り、結果とゲーム状態・イベントを返す
+func (s *gameServer) Play(stream pb.GameService_PlayServer) error {
+	// stream.Send は並行に呼べないため送信は1つのgoroutineにまとめる
+	out := make(chan *pb.PlayResponse, grpcSendBuffer)
+	slow := make(chan struct{}, 1)
+	send := func(res *pb.PlayResponse) {
+		select {
+		case out <- res:
+		default:
+			select {
+			case slow <- struct{}{}:
+			default:
+			}
+		}
+	}
+
+	removeState := services.AddGameStateListener(func(gameState models.GameState) {
+		send(&pb.PlayResponse{Payload: &pb.PlayResponse_State{State: pb.FromGameState(gameState)}})
+	})
+	defer removeState()
+	removeEvent := services.AddGameEventListener(func(event models.GameEvent) {
+		send(&pb.PlayResponse{Payload: &pb.PlayResponse_Event{Event: pb.FromGameEvent(event)}})
+	})
+	defer removeEvent()
+
+	ctx := stream.Context()
+
+	// デバイスはメタデータ x-device-id と authorization: Bearer <token> で認証する
+	authenticatedDevice := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids, tokens := md.Get("x-device-id"), md.Get("authorization"); len(ids) > 0 && len(tokens) > 0 {
+			if err := services.VerifyDeviceToken(ids[0], bearerToken(tokens[0])); err != nil {
+				return status.Error(codes.Unauthenticated, err.Error())
+			}
+			authenticatedDevice = ids[0]
+		}
+	}
+
+	// レート制限に使う接続元のIPアドレス
+	ip := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			ip = host
+		}
+	}
+
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			result := &pb.PlayResponse{Payload: &pb.PlayResponse_Result{Result: handlePlayRequest(req, authenticatedDevice, ip)}}
+			select {
+			case out <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case res := <-out:
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+		case err := <-recvErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case <-slow:
+			return errSlowConsumer
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// 入力を処理して結果を返す
+// 受信用goroutineで呼ばれるためインターセプターではpanicを拾えない
+func handlePlayRequest(req *pb.PlayRequest, authenticatedDevice, ip string) (result *pb.InputResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic while handling play request: %v\n%s", r, debug.Stack())
+			result = &pb.InputResult{Ok: false, Status: "internal error"}
+		}
+	}()
+
+	var err error
+	switch p := req.Payload.(type) {
+	case *pb.PlayRequest_DeviceInput:
+		deviceID := p.DeviceInput.DeviceId
+		if deviceID != authenticatedDevice && services.DeviceAuthRequired(deviceID) {
+			services.RecordDeviceAuthFailure(deviceID, services.ErrDeviceUnauthenticated)
+			return &pb.InputResult{Ok: false, Status: services.ErrDeviceUnauthenticated.Error()}
+		}
+		if err := services.AllowDeviceInput(deviceID, ip); err != nil {
+			return &pb.InputResult{Ok: false, Status: err.Error()}
+		}
+		err = services.HttpProcessInputFromDevice(dto.DeviceInput{
+			DeviceID:  p.DeviceInput.DeviceId,
+			Action:    p.DeviceInput.Action,
+			State:     p.DeviceInput.State,
+			Timestamp: p.DeviceInput.Timestamp,
+			Input:     p.DeviceInput.Input,
+			Power:     p.DeviceInput.Power,
+			Element:   p.DeviceInput.Element,
+		})
+	case *pb.PlayRequest_PlayerJoin:
+		if p.PlayerJoin.PlayerId == "" {
+			return &pb.InputResult{Ok: false, Status: "no player ID provided"}
+		}
+		if userID := p.PlayerJoin.UserId; userID != "" {
+			if err := services.VerifyUserToken(userID, p.PlayerJoin.UserToken); err != nil {
+				return &pb.InputResult{Ok: false, Status: err.Error()}
+			}
+		}
+		services.RegisterPlayer(p.PlayerJoin.PlayerId, p.PlayerJoin.UserId, nil)
+	case *pb.PlayRequest_PlayerInput:
+		err = services.ProcessPlayerAction(p.PlayerInput.PlayerId, p.PlayerInput.Action)
+	default:
+		return &pb.InputResult{Ok: false, Status: "empty request"}
+	}
+
+	switch err {
+	case nil:
+		return &pb.InputResult{Ok: true, Status: "ok"}
+	case services.ErrFighting, services.ErrCountdown, services.ErrChangeFighting:
+		// 正常に処理された結果
+		return &pb.InputResult{Ok: true, Status: err.Error()}
+	default:
+		return &pb.InputResult{Ok: false, Status: err.Error()}
+	}
+}
+
+// Watch ゲーム状態とイベントを配信する
+func (s *gameServer) Watch(_ *pb.WatchRequest, stream pb.GameService_WatchServer) error {
+	out := make(chan *pb.WatchResponse, grpcSendBuffer)
+	slow := make(chan struct{}, 1)
+	send := func(res *pb.WatchResponse) {
+		select {
+		case out <- res:
+		default:
+			select {
+			case slow <- struct{}{}:
+			default:
+			}
+		}
+	}
+
+	removeState := services.AddGameStateListener(func(gameState models.GameState) {
+		send(&pb.WatchResponse{Payload: &pb.WatchResponse_State{State: pb.FromGameState(gameState)}})
+	})
+	defer removeState()
+	removeEvent := services.AddGameEventListener(func(event models.GameEvent) {
+		send(&pb.WatchResponse{Payload: &pb.WatchResponse_Event{Event: pb.FromGameEvent(event)}})
+	})
+	defer removeEvent()
+
+	for {
+		select {
+		case res := <-out:
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+		case <-slow:
+			return errSlowConsumer
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
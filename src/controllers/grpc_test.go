@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"md2s/pb"
+	"md2s/services"
+	"testing"
+)
+
+func TestHandlePlayRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *pb.PlayRequest
+		wantOk     bool
+		wantStatus string
+	}{
+		{"empty request", &pb.PlayRequest{}, false, "empty request"},
+		{
+			"join without player ID",
+			&pb.PlayRequest{Payload: &pb.PlayRequest_PlayerJoin{PlayerJoin: &pb.PlayerJoin{}}},
+			false, "no player ID provided",
+		},
+		{
+			"join as user without token",
+			&pb.PlayRequest{Payload: &pb.PlayRequest_PlayerJoin{PlayerJoin: &pb.PlayerJoin{
+				PlayerId: "player1",
+				UserId:   "00000000-0000-0000-0000-000000000001",
+			}}},
+			false, services.ErrUserUnauthenticated.Error(),
+		},
+		{
+			"input from unpaired device",
+			&pb.PlayRequest{Payload: &pb.PlayRequest_DeviceInput{DeviceInput: &pb.DeviceInput{
+				DeviceId: "test-grpc-unpaired",
+				Action:   "attack",
+				State:    "fighting",
+			}}},
+			false, services.ErrInvalidDevice.Error(),
+		},
+		{
+			"input for missing player",
+			&pb.PlayRequest{Payload: &pb.PlayRequest_PlayerInput{PlayerInput: &pb.PlayerInput{
+				PlayerId: "test-grpc-missing",
+				Action:   "attack",
+			}}},
+			false, "player not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := handlePlayRequest(tt.req, "", "127.0.0.1")
+			if result.Ok != tt.wantOk || result.Status != tt.wantStatus {
+				t.Errorf("result = %v, %q, want %v, %q", result.Ok, result.Status, tt.wantOk, tt.wantStatus)
+			}
+		})
+	}
+}
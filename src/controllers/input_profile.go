@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"errors"
+	"md2s/models"
+	"md2s/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 入力プロファイルの作成・更新
+type inputProfileRequest struct {
+	Name       string               `json:"name"`
+	DeviceType string               `json:"deviceType"`
+	Mappings   models.InputMappings `json:"mappings"`
+	IsDefault  bool                 `json:"isDefault"`
+}
+
+// 入力プロファイルの一覧を取得
+func ListInputProfilesHandler(c *gin.Context) {
+	profiles, err := services.ListInputProfiles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profiles)
+}
+
+// 入力プロファイルを作成
+func CreateInputProfileHandler(c *gin.Context) {
+	saveInputProfile(c, 0, http.StatusCreated)
+}
+
+// 入力プロファイルを更新
+func UpdateInputProfileHandler(c *gin.Context) {
+	profileID, err := strconv.Atoi(c.Param("profileId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid profile ID"})
+		return
+	}
+	saveInputProfile(c, profileID, http.StatusOK)
+}
+
+func saveInputProfile(c *gin.Context, profileID, status int) {
+	var input inputProfileRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	profile := &models.InputProfile{
+		ID:         profileID,
+		Name:       input.Name,
+		DeviceType: input.DeviceType,
+		Mappings:   input.Mappings,
+		IsDefault:  input.IsDefault,
+	}
+	err := services.SaveInputProfile(profile)
+	if err == services.ErrInvalidInputProfile || errors.Is(err, services.ErrUnknownAction) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == services.ErrInputProfileNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(status, profile)
+}
+
+// 入力プロファイルを削除
+func DeleteInputProfileHandler(c *gin.Context) {
+	profileID, err := strconv.Atoi(c.Param("profileId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid profile ID"})
+		return
+	}
+
+	err = services.DeleteInputProfile(profileID)
+	if err == services.ErrInputProfileNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// デバイスに入力プロファイルを割り当てる ({"profileId": null} で解除)
+func AssignInputProfileHandler(c *gin.Context) {
+	var input struct {
+		ProfileID *int `json:"profileId"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	err := services.AssignInputProfile(c.Param("deviceId"), input.ProfileID)
+	if err == services.ErrInputProfileNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
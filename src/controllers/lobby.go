@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"encoding/json"
+	"md2s/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ロビーの状態 (スロット・準備確認・クラス選択) を取得
+func GetLobbyHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, services.GetLobby())
+}
+
+// 準備確認を開始 (参加しているプレイヤーか管理者のみ、認証は他のロビーの操作と同じ)
+func StartReadyCheckHandler(c *gin.Context) {
+	handleLobbyAction(c, func(_, _ string) error {
+		return services.StartReadyCheck()
+	})
+}
+
+// プレイヤーの準備完了
+func ConfirmReadyHandler(c *gin.Context) {
+	handleLobbyAction(c, func(playerID, _ string) error {
+		return services.ConfirmReady(playerID)
+	})
+}
+
+// プレイヤーが準備確認を取り消す
+func CancelReadyCheckHandler(c *gin.Context) {
+	handleLobbyAction(c, func(playerID, _ string) error {
+		return services.CancelReadyCheck(playerID)
+	})
+}
+
+// クラス選択のフェーズでクラスを確定する (class が空の場合は今のクラス (ロードアウト) のまま)
+func PickClassHandler(c *gin.Context) {
+	handleLobbyAction(c, services.PickClass)
+}
+
+// ロビーの操作を、呼び出し元に紐付いたプレイヤーとして処理する
+//
+//	管理者:   Bearerトークンで認証し、playerId のプレイヤーを操作する
+//	デバイス: deviceId と署名 (X-Device-*) で認証し、ペアリングされたプレイヤーを操作する
+//	ユーザー: userId とユーザートークン (Bearer) で認証し、そのユーザーが参加しているプレイヤーを操作する
+func handleLobbyAction(c *gin.Context, action func(playerID, class string) error) {
+	var input struct {
+		PlayerID string `json:"playerId"`
+		DeviceID string `json:"deviceId"`
+		UserID   string `json:"userId"`
+		Class    string `json:"class"`
+	}
+	// 署名の検証にリクエストボディそのものが必要
+	body, err := c.GetRawData()
+	if err != nil || json.Unmarshal(body, &input) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	playerID := input.PlayerID
+	switch {
+	case isAdmin(c):
+	case input.DeviceID != "":
+		err := services.VerifyDeviceSignature(
+			input.DeviceID,
+			c.GetHeader("X-Device-Timestamp"),
+			c.GetHeader("X-Device-Nonce"),
+			c.GetHeader("X-Device-Signature"),
+			body,
+		)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if playerID, err = services.LobbyPlayerByDevice(input.DeviceID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	case input.UserID != "":
+		if err := services.VerifyUserToken(input.UserID, bearerToken(c.GetHeader("Authorization"))); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if playerID, err = services.LobbyPlayerByUser(input.UserID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	respondLobby(c, action(playerID, input.Class))
+}
+
+func respondLobby(c *gin.Context, err error) {
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, services.GetLobby())
+	case services.ErrInvalidPlayerSlot, services.ErrUnknownClass:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrSlotEmpty:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	}
+}
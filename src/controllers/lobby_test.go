@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStartReadyCheckHandlerAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ADMIN_TOKEN", "test-admin")
+	t.Setenv("USER_TOKEN_SECRET", "test-secret")
+
+	tests := []struct {
+		name          string
+		body          string
+		authorization string
+		want          int
+	}{
+		{"認証なし", `{}`, "", http.StatusUnauthorized},
+		{"他のプレイヤーのスロット", `{"playerId":"player1"}`, "", http.StatusUnauthorized},
+		{"不正なユーザートークン", `{"userId":"00000000-0000-0000-0000-000000000001"}`, "Bearer wrong", http.StatusUnauthorized},
+		{"署名のないデバイス", `{"deviceId":"1"}`, "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/lobby/ready-check", StartReadyCheckHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/lobby/ready-check", strings.NewReader(tt.body))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"md2s/dto"
+	"md2s/infra"
+	"md2s/models"
+	"md2s/services"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartMQTTBridge MQTTのデバイス入力トピックを購読し、ゲーム状態をretainedトピックで配信する
+//
+//	入力:   arena/{room}/device/{id}/input  {"action": "...", "state": "...", "seq": 1, "bootId": "...", "timestamp": 0}
+//	結果:   arena/{room}/device/{id}/result
+//	状態:   arena/{room}/state (retained)
+//
+// 組み込みブローカーにはユーザー名をデバイスIDとして接続する
+// 認証が必要なデバイスはパスワードに WebSocket と同じBearerトークンを使う
+func StartMQTTBridge() {
+	room := os.Getenv("MQTT_ROOM")
+	if room == "" {
+		room = "default"
+	}
+	prefix := fmt.Sprintf("arena/%s", room)
+
+	client, err := infra.SetupMQTT(mqttDeviceAuth{prefix: prefix})
+	if err != nil {
+		log.Printf("Failed to set up MQTT: %v", err)
+		return
+	}
+	if client == nil {
+		return
+	}
+
+	// デバイスからの入力を処理
+	err = client.Subscribe(prefix+"/device/+/input", func(topic string, payload []byte) {
+		deviceID := deviceIDFromTopic(topic)
+		if deviceID == "" {
+			log.Printf("Invalid MQTT input topic: %s", topic)
+			return
+		}
+
+		// 認証が必要なデバイスは署名付きの形式で送る
+		//	{"input": {...}, "timestamp": "...", "nonce": "...", "signature": "..."}
+		// 署名の対象は "input" のJSONそのもの
+		var signed struct {
+			Input     json.RawMessage `json:"input"`
+			Timestamp string          `json:"timestamp"`
+			Nonce     string          `json:"nonce"`
+			Signature string          `json:"signature"`
+		}
+		if err := json.Unmarshal(payload, &signed); err != nil {
+			log.Printf("Invalid MQTT input from device %s: %v", deviceID, err)
+			return
+		}
+		if signed.Input != nil {
+			payload = signed.Input
+		}
+		if services.DeviceAuthRequired(deviceID) {
+			if err := services.VerifyDeviceSignature(deviceID, signed.Timestamp, signed.Nonce, signed.Signature, payload); err != nil {
+				publishMQTTResult(client, prefix, deviceID, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		var input dto.DeviceInput
+		if err := json.Unmarshal(payload, &input); err != nil {
+			log.Printf("Invalid MQTT input from device %s: %v", deviceID, err)
+			return
+		}
+		// デバイスIDはトピックのものを使う
+		input.DeviceID = deviceID
+
+		if err := services.AllowDeviceInput(deviceID, ""); err != nil {
+			publishMQTTResult(client, prefix, deviceID, gin.H{"error": err.Error()})
+			return
+		}
+
+		// QoS1 の再配信で同じ入力が二重に適用されないようにする
+		key := services.InputKey{Seq: input.Seq, BootID: input.BootID}
+
+		duplicate, err := services.ProcessDeviceInputOnce(deviceID, key, func() error {
+			return services.HttpProcessInputFromDevice(input)
+		})
+		result := gin.H{"message": "Input processed successfully", "seq": key.String(), "replayed": duplicate}
+		if err != nil {
+			result = gin.H{"error": err.Error(), "seq": key.String(), "replayed": duplicate}
+		}
+
+		publishMQTTResult(client, prefix, deviceID, result)
+	})
+	if err != nil {
+		log.Printf("Failed to subscribe to MQTT input topic: %v", err)
+		return
+	}
+
+	// ゲーム状態をretainedで配信 (後から接続したクライアントも最新状態を受け取れる)
+	services.AddGameStateListener(func(gameState models.GameState) {
+		body, err := json.Marshal(gameState)
+		if err != nil {
+			log.Printf("Failed to encode game state for MQTT: %v", err)
+			return
+		}
+		if err := client.Publish(prefix+"/state", body, true); err != nil {
+			log.Printf("Failed to publish game state to MQTT: %v", err)
+		}
+	})
+
+	log.Printf("MQTT bridge started for room %s", room)
+}
+
+// 組み込みブローカーに接続するデバイスの認証と権限
+// デバイスは自分の入力トピックへの送信と、自分の結果トピック・ゲーム状態の購読だけができる
+type mqttDeviceAuth struct {
+	prefix string
+}
+
+func (a mqttDeviceAuth) Authenticate(username string, password []byte) bool {
+	// トピックの区切りやワイルドカードを含むデバイスIDでは他のデバイスのトピックを指定できてしまう
+	if username == "" || strings.ContainsAny(username, "/+#") {
+		return false
+	}
+	if services.DeviceAuthRequired(username) {
+		return services.VerifyDeviceToken(username, string(password)) == nil
+	}
+	return true
+}
+
+func (a mqttDeviceAuth) Authorize(username, topic string, write bool) bool {
+	if username == "" || strings.ContainsAny(username, "/+#") {
+		return false
+	}
+	device := fmt.Sprintf("%s/device/%s/", a.prefix, username)
+	if write {
+		return topic == device+"input"
+	}
+	return topic == device+"result" || topic == a.prefix+"/state"
+}
+
+// 入力の処理結果をデバイスに返す
+func publishMQTTResult(client infra.MQTTClient, prefix, deviceID string, result gin.H) {
+	body, _ := json.Marshal(result)
+	if err := client.Publish(fmt.Sprintf("%s/device/%s/result", prefix, deviceID), body, false); err != nil {
+		log.Printf("Failed to publish MQTT result to device %s: %v", deviceID, err)
+	}
+}
+
+// arena/{room}/device/{id}/input からデバイスIDを取り出す
+func deviceIDFromTopic(topic string) string {
+	parts := strings.Split(topic, "/")
+	if len(parts) != 5 || parts[2] != "device" || parts[4] != "input" {
+		return ""
+	}
+	return parts[3]
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import "testing"
+
+func TestMQTTDeviceAuthAuthorize(t *testing.T) {
+	auth := mqttDeviceAuth{prefix: "arena/room1"}
+
+	tests := []struct {
+		name     string
+		username string
+		topic    string
+		write    bool
+		want     bool
+	}{
+		{"publish own input", "dev1", "arena/room1/device/dev1/input", true, true},
+		{"publish other device input", "dev1", "arena/room1/device/dev2/input", true, false},
+		{"publish own result", "dev1", "arena/room1/device/dev1/result", true, false},
+		{"publish state", "dev1", "arena/room1/state", true, false},
+		{"subscribe own result", "dev1", "arena/room1/device/dev1/result", false, true},
+		{"subscribe state", "dev1", "arena/room1/state", false, true},
+		{"subscribe other device result", "dev1", "arena/room1/device/dev2/result", false, false},
+		{"subscribe wildcard", "dev1", "arena/room1/device/+/result", false, false},
+		{"wildcard username", "+", "arena/room1/device/+/input", true, false},
+		{"username with slash", "dev1/x", "arena/room1/device/dev1/x/input", true, false},
+		{"empty username", "", "arena/room1/state", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := auth.Authorize(tt.username, tt.topic, tt.write); got != tt.want {
+				t.Errorf("Authorize(%q, %q, %v) = %v, want %v", tt.username, tt.topic, tt.write, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceIDFromTopic(t *testing.T) {
+	tests := []struct {
+		topic string
+		want  string
+	}{
+		{"arena/room1/device/dev1/input", "dev1"},
+		{"arena/room1/device/dev1/result", ""},
+		{"arena/room1/device/input", ""},
+		{"arena/room1/device/a/b/input", ""},
+		{"arena/room1/players/dev1/input", ""},
+	}
+
+	for _, tt := range tests {
+		if got := deviceIDFromTopic(tt.topic); got != tt.want {
+			t.Errorf("deviceIDFromTopic(%q) = %q, want %q", tt.topic, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"encoding/json"
+	"md2s/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// プレイヤーの画面に表示するペアリングコードを発行
+//
+//	管理者:   Bearerトークンで認証し、playerId のスロットのコードを発行する
+//	ユーザー: userId とユーザートークン (Bearer) で認証し、そのユーザーが参加しているスロットのコードを発行する
+func CreatePairingCodeHandler(c *gin.Context) {
+	var input struct {
+		PlayerID string `json:"playerId"`
+		UserID   string `json:"userId"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	playerID := input.PlayerID
+	switch {
+	case isAdmin(c):
+	case input.UserID != "":
+		if err := services.VerifyUserToken(input.UserID, bearerToken(c.GetHeader("Authorization"))); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var err error
+		if playerID, err = services.LobbyPlayerByUser(input.UserID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	code, err := services.CreatePairingCode(playerID)
+	if err == services.ErrInvalidPlayerSlot {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, code)
+}
+
+// デバイスがペアリングコードを送信してプレイヤースロットと紐付ける
+// デバイスはシークレットで署名して送る (シークレットのないデバイスは管理者がペアリングする)
+func PairDeviceHandler(c *gin.Context) {
+	var input struct {
+		DeviceID string `json:"deviceId"`
+		Code     string `json:"code"`
+	}
+	// 署名の検証にリクエストボディそのものが必要
+	body, err := c.GetRawData()
+	if err != nil || json.Unmarshal(body, &input) != nil || input.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if !isAdmin(c) {
+		err := services.VerifyDeviceSignature(
+			input.DeviceID,
+			c.GetHeader("X-Device-Timestamp"),
+			c.GetHeader("X-Device-Nonce"),
+			c.GetHeader("X-Device-Signature"),
+			body,
+		)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	binding, err := services.PairDevice(input.DeviceID, input.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, binding)
+}
+
+// デバイスの紐付け一覧を取得
+func ListDeviceBindingsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, services.ListDeviceBindings())
+}
+
+// デバイスの紐付けを解除
+func RevokeDeviceBindingHandler(c *gin.Context) {
+	if err := services.RevokeDeviceBinding(c.Param("deviceId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"encoding/json"
 	"log"
 	"md2s/services"
 
@@ -8,8 +9,16 @@ import (
 )
 
 // HandlePlayerWebSocket プレイヤーのWebSocket接続を処理
+// userId を指定するとユーザーのロードアウトを適用する (Authorization ヘッダーのユーザートークンで認証する)
+// ヘッダーを付けられないクライアントは、接続後に {"type": "auth", "userId": ..., "token": ...} を送る
+// ロビーの操作は接続したプレイヤーとして {"type": "ready"}, {"type": "cancel"}, {"type": "pick", "class": ...} を送る
 func HandlePlayerWebSocket(c *gin.Context) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	userID, ok := connectingUser(c)
+	if !ok {
+		return
+	}
+
+	conn, err := upgradeWebSocket(c)
 	if err != nil {
 		log.Printf("Failed to upgrade connection: %v", err)
 		return
@@ -24,7 +33,7 @@ func HandlePlayerWebSocket(c *gin.Context) {
 	}
 
 	// プレイヤーを登録
-	services.RegisterPlayer(playerID, conn)
+	services.RegisterPlayer(playerID, userID, conn)
 
 	// メッセージの受信
 	for {
@@ -34,8 +43,45 @@ func HandlePlayerWebSocket(c *gin.Context) {
 			break
 		}
 
+		if handlePlayerMessage(playerID, message) {
+			continue
+		}
+
 		// 入力を処理
 		services.ProcessInputFromPlayer(playerID, message)
 	}
 
 }
+
+// ユーザーの認証・ロビーの操作のメッセージを処理 (入力であれば false)
+func handlePlayerMessage(playerID string, message []byte) bool {
+	var msg struct {
+		Type   string `json:"type"`
+		UserID string `json:"userId"`
+		Token  string `json:"token"`
+		Class  string `json:"class"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return false
+	}
+
+	var err error
+	switch msg.Type {
+	case "auth":
+		if err = services.VerifyUserToken(msg.UserID, msg.Token); err == nil {
+			err = services.ClaimPlayerUser(playerID, msg.UserID)
+		}
+	case "ready":
+		err = services.ConfirmReady(playerID)
+	case "cancel":
+		err = services.CancelReadyCheck(playerID)
+	case "pick":
+		err = services.PickClass(playerID, msg.Class)
+	default:
+		return false
+	}
+	if err != nil {
+		log.Printf("Player %s %s failed: %v", playerID, msg.Type, err)
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"md2s/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 試合のパワーアップの状態 (シード・出現中のパワーアップ) を取得
+func GetPowerUpsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, services.GetPowerUps())
+}
+
+// 次の試合のパワーアップのシードを指定 (試合を再現する場合に使う、0 でルール設定に戻す)
+func SetNextPowerUpSeedHandler(c *gin.Context) {
+	var input struct {
+		Seed uint64 `json:"seed"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	services.SetNextPowerUpSeed(input.Seed)
+	c.JSON(http.StatusOK, services.GetPowerUps())
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"md2s/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 不正の疑いやレート制限のあったデバイスの一覧を取得
+func ListFlaggedDevicesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, services.ListDeviceCheatStatuses())
+}
+
+// デバイスの入力を停止
+func SuspendDeviceHandler(c *gin.Context) {
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	// 理由は省略できる
+	_ = c.ShouldBindJSON(&input)
+
+	c.JSON(http.StatusOK, services.SuspendDevice(c.Param("deviceId"), input.Reason))
+}
+
+// デバイスの検知記録を消して制限を解除
+func ClearDeviceFlagsHandler(c *gin.Context) {
+	services.ClearDeviceFlags(c.Param("deviceId"))
+	c.Status(http.StatusNoContent)
+}
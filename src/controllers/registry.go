@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"encoding/json"
+	"md2s/dto"
+	"md2s/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 登録済みのデバイスとヘルス状態の一覧を取得
+func ListDevicesHandler(c *gin.Context) {
+	fleet, err := services.ListDeviceFleet()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, fleet)
+}
+
+// デバイスを登録・更新
+func SaveDeviceHandler(c *gin.Context) {
+	var input dto.DeviceInfo
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	device, err := services.SaveDeviceInfo(c.Param("deviceId"), input)
+	if err == services.ErrInvalidOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, device)
+}
+
+// デバイスを登録から削除
+func DeleteDeviceHandler(c *gin.Context) {
+	if err := services.DeleteDevice(c.Param("deviceId")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// デバイスからテレメトリを受け取る (WebSocketを使わないデバイス用)
+func DeviceTelemetryHandler(c *gin.Context) {
+	var input dto.DeviceTelemetry
+
+	body, err := c.GetRawData()
+	if err != nil || json.Unmarshal(body, &input) != nil || input.DeviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if !authenticateDeviceRequest(c, input.DeviceID, body) {
+		return
+	}
+
+	err = services.RecordDeviceTelemetry(input)
+	if err == services.ErrUnknownDevice {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
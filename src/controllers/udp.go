@@ -0,0 +1,353 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"log"
+	"md2s/dto"
+	"md2s/services"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// UDP入力フレーム (ビッグエンディアン)
+//
+//	0      version (1)
+//	1      flags (bit0: 認証タグあり、bit1: 強さあり)
+//	2      デバイスIDの長さ n
+//	3      デバイスID (n バイト)
+//	3+n    シーケンス番号 (uint32)
+//	7+n    アクションコード (uint8)
+//	8+n    ステートコード (uint8)
+//	9+n    デバイスのタイムスタンプ ミリ秒 (uint64)
+//	17+n   振りの強さ 0〜255 (uint8、flags bit1 の場合のみ)
+//	       認証タグ HMAC-SHA256 の先頭16バイト (flags bit0 の場合のみ、フレームの末尾)
+//
+// ACKフレーム
+//
+//	0  version (1)
+//	1  ステータスコード
+//	2  シーケンス番号 (uint32)
+//	6  HP (uint16)
+//	8  MP (uint16)
+const (
+	udpFrameVersion = 1
+	udpFlagAuthTag  = 0x01
+	udpFlagPower    = 0x02
+	udpAuthTagSize  = 16
+	udpMaxDeviceID  = 32
+	udpAckSize      = 10
+
+	// この時間以上フレームが届かなければシーケンス番号をリセットする (デバイスの再起動を想定)
+	udpSequenceTimeout = 10 * time.Second
+)
+
+// アクションコード
+// switch_element は属性を指定できないので、切り替える順番の次の属性にする
+var udpActions = map[byte]string{
+	0: "none",
+	1: "attack",
+	2: "defend",
+	3: "collection",
+	4: "charge_start",
+	5: "charge_release",
+	6: "signature",
+	7: "claim",
+	8: "switch_element",
+}
+
+// ステートコード
+var udpStates = map[byte]string{
+	0: "",
+	1: "noReady",
+	2: "ready",
+	3: "fighting",
+}
+
+// ACKのステータスコード
+const (
+	udpStatusOK byte = iota
+	udpStatusDuplicate
+	udpStatusOutOfOrder
+	udpStatusUnauthorized
+	udpStatusInvalidDevice
+	udpStatusGameOver
+	udpStatusNotReady
+	udpStatusOpponentNotReady
+	udpStatusCountdown
+	udpStatusUnknownAction
+	udpStatusError
+	udpStatusRateLimited
+	udpStatusSuspended
+	udpStatusStunned
+	udpStatusRespawning
+)
+
+var errInvalidFrame = errors.New("invalid frame")
+
+// UDP入力フレーム
+type udpFrame struct {
+	DeviceID  string
+	Sequence  uint32
+	Action    string
+	State     string
+	Timestamp uint64
+	Power     *float64
+	signed    []byte // 認証タグの対象となるバイト列
+	tag       []byte
+}
+
+// デバイスごとのシーケンス番号
+type udpSequence struct {
+	last     uint32
+	lastSeen time.Time
+}
+
+var (
+	udpSequences   = map[string]*udpSequence{}
+	udpSequencesMu sync.Mutex
+)
+
+// StartUDPListener UDP_ADDR が設定されている場合、バイナリ入力を受け付けるUDPリスナーを開始
+func StartUDPListener() {
+	addr := os.Getenv("UDP_ADDR")
+	if addr == "" {
+		return
+	}
+
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		log.Printf("Failed to start UDP listener: %v", err)
+		return
+	}
+	log.Printf("UDP listener started on %s", addr)
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, remote, err := conn.ReadFrom(buf)
+			if errors.Is(err, net.ErrClosed) {
+				log.Printf("UDP listener stopped")
+				return
+			}
+			if err != nil {
+				log.Printf("Error reading UDP frame: %v", err)
+				continue
+			}
+
+			frame, err := decodeUDPFrame(buf[:n])
+			if err != nil {
+				log.Printf("Invalid UDP frame from %s: %v", remote, err)
+				continue
+			}
+
+			ip := ""
+			if addr, ok := remote.(*net.UDPAddr); ok {
+				ip = addr.IP.String()
+			}
+
+			status := handleUDPFrame(frame, ip)
+			hp, mp, _ := services.GetDevicePlayerStatus(frame.DeviceID)
+			if _, err := conn.WriteTo(encodeUDPAck(status, frame.Sequence, hp, mp), remote); err != nil {
+				log.Printf("Error sending UDP ack to %s: %v", remote, err)
+			}
+		}
+	}()
+}
+
+// フレームを検証して入力を処理し、ステータスコードを返す
+func handleUDPFrame(frame *udpFrame, ip string) byte {
+	// ペアリングされていないデバイスの状態 (シーケンス番号など) は記録しない
+	if !services.DevicePaired(frame.DeviceID) {
+		return udpStatusInvalidDevice
+	}
+	if !verifyUDPFrame(frame) {
+		return udpStatusUnauthorized
+	}
+
+	switch services.AllowDeviceInput(frame.DeviceID, ip) {
+	case services.ErrInvalidDevice:
+		return udpStatusInvalidDevice
+	case services.ErrRateLimited:
+		return udpStatusRateLimited
+	case services.ErrDeviceSuspended:
+		return udpStatusSuspended
+	}
+
+	if status, ok := checkUDPSequence(frame.DeviceID, frame.Sequence); !ok {
+		return status
+	}
+
+	err := services.HttpProcessInputFromDevice(dto.DeviceInput{
+		DeviceID:  frame.DeviceID,
+		Action:    frame.Action,
+		State:     frame.State,
+		Timestamp: int64(frame.Timestamp),
+		Power:     frame.Power,
+	})
+	switch err {
+	case nil, services.ErrFighting, services.ErrChangeFighting:
+		return udpStatusOK
+	case services.ErrInvalidDevice:
+		return udpStatusInvalidDevice
+	case services.ErrGameOver:
+		return udpStatusGameOver
+	case services.ErrPlayerNotReady:
+		return udpStatusNotReady
+	case services.ErrOpponentNotReady:
+		return udpStatusOpponentNotReady
+	case services.ErrCountdown:
+		return udpStatusCountdown
+	case services.ErrUnknownAction:
+		return udpStatusUnknownAction
+	case services.ErrDeviceSuspended:
+		return udpStatusSuspended
+	case services.ErrStunned:
+		return udpStatusStunned
+	case services.ErrRespawning:
+		return udpStatusRespawning
+	default:
+		log.Printf("Error processing UDP input from device %s: %v", frame.DeviceID, err)
+		return udpStatusError
+	}
+}
+
+// 重複・順序違いのフレームを弾く
+func checkUDPSequence(deviceID string, seq uint32) (byte, bool) {
+	udpSequencesMu.Lock()
+	defer udpSequencesMu.Unlock()
+
+	now := time.Now()
+	// しばらくフレームが届いていないデバイスの記録は削除する
+	for id, s := range udpSequences {
+		if now.Sub(s.lastSeen) > udpSequenceTimeout {
+			delete(udpSequences, id)
+		}
+	}
+
+	s, exists := udpSequences[deviceID]
+	if !exists {
+		udpSequences[deviceID] = &udpSequence{last: seq, lastSeen: now}
+		return udpStatusOK, true
+	}
+
+	// uint32 の折り返しを考慮して比較
+	diff := int32(seq - s.last)
+	if diff == 0 {
+		return udpStatusDuplicate, false
+	}
+	if diff < 0 {
+		return udpStatusOutOfOrder, false
+	}
+
+	s.last = seq
+	s.lastSeen = now
+	return udpStatusOK, true
+}
+
+// 認証が必要なデバイスは、デバイスのシークレットで計算した認証タグを必須にする
+// 再送はシーケンス番号で防ぐ
+func verifyUDPFrame(frame *udpFrame) bool {
+	if !services.DeviceAuthRequired(frame.DeviceID) {
+		return true
+	}
+
+	secret, exists := services.DeviceSecret(frame.DeviceID)
+	if !exists || frame.tag == nil {
+		services.RecordDeviceAuthFailure(frame.DeviceID, services.ErrDeviceUnauthenticated)
+		return false
+	}
+
+	// シーケンス番号がリセットされた後に古いフレームを再送されないようにする
+	if !services.ValidSignatureTimestamp(int64(frame.Timestamp)) {
+		services.RecordDeviceAuthFailure(frame.DeviceID, services.ErrSignatureExpired)
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(frame.signed)
+	if !hmac.Equal(mac.Sum(nil)[:udpAuthTagSize], frame.tag) {
+		services.RecordDeviceAuthFailure(frame.DeviceID, services.ErrInvalidSignature)
+		return false
+	}
+	return true
+}
+
+func decodeUDPFrame(b []byte) (*udpFrame, error) {
+	if len(b) < 3 || b[0] != udpFrameVersion {
+		return nil, errInvalidFrame
+	}
+
+	flags := b[1]
+	n := int(b[2])
+	if n == 0 || n > udpMaxDeviceID {
+		return nil, errInvalidFrame
+	}
+
+	size := 3 + n + 4 + 1 + 1 + 8
+	if flags&udpFlagPower != 0 {
+		size++
+	}
+	if flags&udpFlagAuthTag != 0 {
+		size += udpAuthTagSize
+	}
+	if len(b) != size {
+		return nil, errInvalidFrame
+	}
+
+	p := 3 + n
+	frame := &udpFrame{
+		DeviceID:  string(b[3:p]),
+		Sequence:  binary.BigEndian.Uint32(b[p:]),
+		Timestamp: binary.BigEndian.Uint64(b[p+6:]),
+	}
+
+	action, ok := udpActions[b[p+4]]
+	if !ok {
+		return nil, errInvalidFrame
+	}
+	state, ok := udpStates[b[p+5]]
+	if !ok {
+		return nil, errInvalidFrame
+	}
+	frame.Action = action
+	frame.State = state
+
+	end := p + 14
+	if flags&udpFlagPower != 0 {
+		power := float64(b[end]) / 255
+		frame.Power = &power
+		end++
+	}
+
+	if flags&udpFlagAuthTag != 0 {
+		frame.signed = b[:end]
+		frame.tag = b[end:]
+	}
+
+	return frame, nil
+}
+
+func encodeUDPAck(status byte, seq uint32, hp, mp int) []byte {
+	b := make([]byte, udpAckSize)
+	b[0] = udpFrameVersion
+	b[1] = status
+	binary.BigEndian.PutUint32(b[2:], seq)
+	binary.BigEndian.PutUint16(b[6:], uint16(clampUint16(hp)))
+	binary.BigEndian.PutUint16(b[8:], uint16(clampUint16(mp)))
+	return b
+}
+
+func clampUint16(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 0xffff {
+		return 0xffff
+	}
+	return v
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// テスト用のUDP入力フレームを組み立てる
+func buildUDPFrame(deviceID string, seq uint32, action, state byte, power *byte, tag []byte) []byte {
+	flags := byte(0)
+	if power != nil {
+		flags |= udpFlagPower
+	}
+	if tag != nil {
+		flags |= udpFlagAuthTag
+	}
+	b := []byte{udpFrameVersion, flags, byte(len(deviceID))}
+	b = append(b, deviceID...)
+	b = binary.BigEndian.AppendUint32(b, seq)
+	b = append(b, action, state)
+	b = binary.BigEndian.AppendUint64(b, 1700000000000)
+	if power != nil {
+		b = append(b, *power)
+	}
+	return append(b, tag...)
+}
+
+func TestDecodeUDPFrame(t *testing.T) {
+	power := byte(255)
+	tag := make([]byte, udpAuthTagSize)
+
+	tests := []struct {
+		name       string
+		frame      []byte
+		wantErr    bool
+		wantAction string
+		wantState  string
+		wantPower  *float64
+		wantTag    bool
+	}{
+		{"attack", buildUDPFrame("dev1", 7, 1, 3, nil, nil), false, "attack", "fighting", nil, false},
+		{"ready with power", buildUDPFrame("dev1", 7, 0, 2, &power, nil), false, "none", "ready", func() *float64 { p := 1.0; return &p }(), false},
+		{"with auth tag", buildUDPFrame("dev1", 7, 4, 3, nil, tag), false, "charge_start", "fighting", nil, true},
+		{"new actions", buildUDPFrame("dev1", 7, 8, 3, nil, nil), false, "switch_element", "fighting", nil, false},
+		{"unknown action", buildUDPFrame("dev1", 7, 99, 3, nil, nil), true, "", "", nil, false},
+		{"unknown state", buildUDPFrame("dev1", 7, 1, 99, nil, nil), true, "", "", nil, false},
+		{"truncated", buildUDPFrame("dev1", 7, 1, 3, nil, nil)[:10], true, "", "", nil, false},
+		{"trailing bytes", append(buildUDPFrame("dev1", 7, 1, 3, nil, nil), 0), true, "", "", nil, false},
+		{"wrong version", append([]byte{2}, buildUDPFrame("dev1", 7, 1, 3, nil, nil)[1:]...), true, "", "", nil, false},
+		{"empty device ID", buildUDPFrame("", 7, 1, 3, nil, nil), true, "", "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := decodeUDPFrame(tt.frame)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeUDPFrame() = %+v, want error", frame)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeUDPFrame() error = %v", err)
+			}
+			if frame.DeviceID != "dev1" || frame.Sequence != 7 || frame.Timestamp != 1700000000000 {
+				t.Errorf("header = %s, %d, %d", frame.DeviceID, frame.Sequence, frame.Timestamp)
+			}
+			if frame.Action != tt.wantAction || frame.State != tt.wantState {
+				t.Errorf("action, state = %s, %s, want %s, %s", frame.Action, frame.State, tt.wantAction, tt.wantState)
+			}
+			if (frame.Power == nil) != (tt.wantPower == nil) || frame.Power != nil && *frame.Power != *tt.wantPower {
+				t.Errorf("power = %v, want %v", frame.Power, tt.wantPower)
+			}
+			if (frame.tag != nil) != tt.wantTag {
+				t.Errorf("tag = %v, want tag %v", frame.tag, tt.wantTag)
+			}
+		})
+	}
+}
+
+func TestCheckUDPSequence(t *testing.T) {
+	tests := []struct {
+		name string
+		seqs []uint32
+		want []byte
+	}{
+		{"in order", []uint32{1, 2, 5}, []byte{udpStatusOK, udpStatusOK, udpStatusOK}},
+		{"duplicate", []uint32{1, 1}, []byte{udpStatusOK, udpStatusDuplicate}},
+		{"out of order", []uint32{5, 3}, []byte{udpStatusOK, udpStatusOutOfOrder}},
+		{"wraps around", []uint32{0xffffffff, 0}, []byte{udpStatusOK, udpStatusOK}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceID := "test-udp-" + tt.name
+			defer func() {
+				udpSequencesMu.Lock()
+				delete(udpSequences, deviceID)
+				udpSequencesMu.Unlock()
+			}()
+			for i, seq := range tt.seqs {
+				if got, _ := checkUDPSequence(deviceID, seq); got != tt.want[i] {
+					t.Errorf("frame %d (seq %d): status = %d, want %d", i, seq, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHandleUDPFrameUnpairedDevice(t *testing.T) {
+	frame, err := decodeUDPFrame(buildUDPFrame("unpaired", 1, 1, 3, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := handleUDPFrame(frame, "127.0.0.1"); status != udpStatusInvalidDevice {
+		t.Errorf("status = %d, want %d", status, udpStatusInvalidDevice)
+	}
+
+	udpSequencesMu.Lock()
+	_, recorded := udpSequences["unpaired"]
+	udpSequencesMu.Unlock()
+	if recorded {
+		t.Errorf("sequence was recorded for an unpaired device")
+	}
+}
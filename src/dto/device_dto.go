@@ -0,0 +1,59 @@
+package dto
+
+// デバイスからの入力 (HTTP / MQTT / UDP / gRPC 共通)
+type DeviceInput struct {
+	DeviceID string `json:"deviceId"`
+	Action   string `json:"action"`
+	State    string `json:"state"`
+	// ボタンID・キーコードなどの生の入力 (入力プロファイルでアクションに変換する)
+	Input string `json:"input"`
+	// デバイスの時刻 (UNIXミリ秒)。時刻同期済みの場合はラグ補正に使う
+	Timestamp int64 `json:"timestamp"`
+	// 再送時の重複適用を防ぐためのシーケンス番号
+	Seq *uint64 `json:"seq"`
+	// シーケンス番号を数え始めた起動のID (起動ごとに変える)
+	// 再起動でシーケンス番号が戻っても、以前の起動の入力と区別できる
+	BootID string `json:"bootId"`
+	// ジェスチャー認識の確からしさ (0〜1)。省略時は 1
+	Confidence *float64 `json:"confidence"`
+	// 振りの強さ (0〜1、範囲外は丸める)。省略時は 1
+	Power *float64 `json:"power"`
+	// この入力の属性 ("fire" など)。プレイヤーの属性と違う場合は切り替えのMPを消費して切り替えてから行動する
+	// 省略時はプレイヤーの属性のまま ("switch_element" の場合は切り替える順番の次の属性)
+	Element string `json:"element"`
+}
+
+// 時刻同期の結果 (NTPと同じ4つのタイムスタンプ、UNIXミリ秒)
+type ClockSyncResult struct {
+	DeviceID string `json:"deviceId"`
+	T0       int64  `json:"t0"` // デバイスが送信した時刻
+	T1       int64  `json:"t1"` // サーバーが受信した時刻
+	T2       int64  `json:"t2"` // サーバーが応答した時刻
+	T3       int64  `json:"t3"` // デバイスが応答を受信した時刻
+}
+
+// デバイスの登録・更新
+type DeviceInfo struct {
+	Name    string `json:"name"`
+	OwnerID string `json:"ownerId"`
+	Type    string `json:"type"`
+	// ファームウェアの段階的な配信に使うグループ (省略時は "default")
+	Group string `json:"group"`
+}
+
+// デバイスから送られるテレメトリ (送られた項目だけ更新する)
+type DeviceTelemetry struct {
+	DeviceID        string `json:"deviceId"`
+	BatteryLevel    *int   `json:"batteryLevel"`    // バッテリー残量 (%)
+	RSSI            *int   `json:"rssi"`            // 電波強度 (dBm)
+	FirmwareVersion string `json:"firmwareVersion"` // ファームウェアのバージョン
+	ErrorCount      *int   `json:"errorCount"`      // 起動してからのエラー回数
+}
+
+// ファームウェア更新の結果
+type FirmwareUpdateReport struct {
+	DeviceID string `json:"deviceId"`
+	Version  string `json:"version"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error"`
+}
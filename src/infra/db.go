@@ -2,29 +2,36 @@ package infra
 
 import (
 	"fmt"
-	"log"
 	"os"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// DBConfigured データベースの接続先が設定されているか (DATABASE_URL か DB_HOST)
+func DBConfigured() bool {
+	return os.Getenv("DATABASE_URL") != "" || os.Getenv("DB_HOST") != ""
+}
+
 // SetupDB initializes the database connection and creates tables if they don't exist
-func SetupDB() *gorm.DB {
-	// データソース名を環境変数から取得
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Tokyo",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
+func SetupDB() (*gorm.DB, error) {
+	// データソース名を環境変数から取得 (DATABASE_URL があればそちらを使う)
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		dsn = fmt.Sprintf(
+			"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Tokyo",
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_USER"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_NAME"),
+			os.Getenv("DB_PORT"),
+		)
+	}
 
 	// データベースに接続
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	// マイグレーション
@@ -93,12 +100,94 @@ func SetupDB() *gorm.DB {
 		tag_id INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
 		UNIQUE (article_id, tag_id)
 	);
+
+	-- デバイステーブル
+	CREATE TABLE IF NOT EXISTS devices (
+		id VARCHAR(64) PRIMARY KEY,
+		name VARCHAR(50),
+		owner_id UUID REFERENCES users(id) ON DELETE SET NULL,
+		type VARCHAR(30),
+		secret VARCHAR(64),
+		firmware_version VARCHAR(30),
+		battery_level INT,
+		rssi INT,
+		error_count INT DEFAULT 0,
+		last_seen_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- ファームウェアテーブル
+	CREATE TABLE IF NOT EXISTS firmwares (
+		id SERIAL PRIMARY KEY,
+		device_type VARCHAR(30) NOT NULL,
+		version VARCHAR(30) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		size BIGINT NOT NULL,
+		file_path VARCHAR(255) NOT NULL,
+		notes TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (device_type, version)
+	);
+
+	-- ファームウェアの段階的な配信 (デバイスグループごとの配信割合)
+	CREATE TABLE IF NOT EXISTS firmware_rollouts (
+		id SERIAL PRIMARY KEY,
+		firmware_id INT NOT NULL REFERENCES firmwares(id) ON DELETE CASCADE,
+		device_group VARCHAR(30) NOT NULL,
+		percentage INT NOT NULL DEFAULT 0 CHECK (percentage BETWEEN 0 AND 100),
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (firmware_id, device_group)
+	);
+
+	-- デバイスのグループとファームウェア更新の結果
+	ALTER TABLE devices ADD COLUMN IF NOT EXISTS device_group VARCHAR(30) DEFAULT 'default';
+	ALTER TABLE devices ADD COLUMN IF NOT EXISTS last_update_version VARCHAR(30);
+	ALTER TABLE devices ADD COLUMN IF NOT EXISTS last_update_status VARCHAR(10);
+	ALTER TABLE devices ADD COLUMN IF NOT EXISTS last_update_error TEXT;
+	ALTER TABLE devices ADD COLUMN IF NOT EXISTS last_update_at TIMESTAMP;
+
+	-- ラベル付きのジェスチャーのサンプル
+	CREATE TABLE IF NOT EXISTS gesture_samples (
+		id SERIAL PRIMARY KEY,
+		device_id VARCHAR(64) NOT NULL,
+		user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+		label VARCHAR(30) NOT NULL,
+		samples JSONB NOT NULL,
+		duration_ms BIGINT NOT NULL DEFAULT 0,
+		recognized_action VARCHAR(30),
+		recognized_confidence DOUBLE PRECISION,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS gesture_samples_label_idx ON gesture_samples (label);
+
+	-- デバイスの種類ごとの入力プロファイル (ボタンID・ジェスチャー名・キーコードなど → ゲームのアクション)
+	CREATE TABLE IF NOT EXISTS input_profiles (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(50) NOT NULL UNIQUE,
+		device_type VARCHAR(30) NOT NULL,
+		mappings JSONB NOT NULL DEFAULT '{}',
+		is_default BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	ALTER TABLE devices ADD COLUMN IF NOT EXISTS input_profile_id INT REFERENCES input_profiles(id) ON DELETE SET NULL;
+
+	-- デバイスとプレイヤースロットの紐付け (再起動後も保持する)
+	ALTER TABLE devices ADD COLUMN IF NOT EXISTS paired_player VARCHAR(30);
+	ALTER TABLE devices ADD COLUMN IF NOT EXISTS paired_at TIMESTAMP;
+
+	-- ユーザーのロードアウト (キャラクタークラス)
+	CREATE TABLE IF NOT EXISTS loadouts (
+		user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		class VARCHAR(30) NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
 `
 
 	// SQL実行
 	if err := db.Exec(initSQL).Error; err != nil {
-		log.Fatalf("Error executing initSQL: %v", err)
+		return nil, fmt.Errorf("error executing initSQL: %w", err)
 	}
 
-	return db
+	return db, nil
 }
@@ -0,0 +1,201 @@
+package infra
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+// MQTTClient 組み込みブローカーと外部ブローカーの共通インターフェース
+type MQTTClient interface {
+	Subscribe(filter string, handler func(topic string, payload []byte)) error
+	Publish(topic string, payload []byte, retained bool) error
+}
+
+// MQTTAuth 組み込みブローカーに接続するクライアントの認証とトピックの権限
+type MQTTAuth interface {
+	// ユーザー名とパスワードで接続を許可するか
+	Authenticate(username string, password []byte) bool
+	// topic への送信 (write=true) または購読を許可するか
+	Authorize(username, topic string, write bool) bool
+}
+
+// SetupMQTT MQTT_MODE に応じてブローカーへ接続する
+// MQTT_MODE が未設定の場合は nil を返す
+// authorizer は組み込みブローカーでのみ使う (外部ブローカーの認証はブローカー側で設定する)
+func SetupMQTT(authorizer MQTTAuth) (MQTTClient, error) {
+	switch os.Getenv("MQTT_MODE") {
+	case "":
+		return nil, nil
+	case "embedded":
+		return setupEmbeddedMQTT(authorizer)
+	case "external":
+		return setupExternalMQTT()
+	default:
+		return nil, fmt.Errorf("unknown MQTT_MODE: %s", os.Getenv("MQTT_MODE"))
+	}
+}
+
+// 組み込みブローカー (ローカル開発用)
+type embeddedMQTT struct {
+	server *mqtt.Server
+	nextID int
+}
+
+func setupEmbeddedMQTT(authorizer MQTTAuth) (MQTTClient, error) {
+	addr := os.Getenv("MQTT_LISTEN_ADDR")
+	if addr == "" {
+		addr = ":1883" // デフォルトポート
+	}
+
+	server := mqtt.New(&mqtt.Options{InlineClient: true})
+
+	// 共有の認証情報 (MQTT_USERNAME / MQTT_PASSWORD) を持つクライアントは全てのトピックを使える
+	// それ以外のクライアントは authorizer で認証し、使えるトピックを制限する
+	hook := &embeddedAuthHook{
+		username:   os.Getenv("MQTT_USERNAME"),
+		password:   []byte(os.Getenv("MQTT_PASSWORD")),
+		authorizer: authorizer,
+	}
+	if err := server.AddHook(hook, nil); err != nil {
+		return nil, err
+	}
+
+	tcp := listeners.NewTCP(listeners.Config{ID: "tcp", Address: addr})
+	if err := server.AddListener(tcp); err != nil {
+		return nil, err
+	}
+
+	if err := server.Serve(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Embedded MQTT broker listening on %s", addr)
+	return &embeddedMQTT{server: server}, nil
+}
+
+// 組み込みブローカーの認証とACL
+type embeddedAuthHook struct {
+	mqtt.HookBase
+	username   string
+	password   []byte
+	authorizer MQTTAuth
+}
+
+func (h *embeddedAuthHook) ID() string {
+	return "md2s-auth"
+}
+
+func (h *embeddedAuthHook) Provides(b byte) bool {
+	return bytes.Contains([]byte{mqtt.OnConnectAuthenticate, mqtt.OnACLCheck}, []byte{b})
+}
+
+func (h *embeddedAuthHook) OnConnectAuthenticate(cl *mqtt.Client, pk packets.Packet) bool {
+	username, password := string(pk.Connect.Username), pk.Connect.Password
+	if h.username != "" && username == h.username {
+		return bytes.Equal(password, h.password)
+	}
+	return h.authorizer != nil && h.authorizer.Authenticate(username, password)
+}
+
+func (h *embeddedAuthHook) OnACLCheck(cl *mqtt.Client, topic string, write bool) bool {
+	// 共有のユーザー名では共有のパスワードでしか接続できない
+	if h.username != "" && string(cl.Properties.Username) == h.username {
+		return true
+	}
+	return h.authorizer != nil && h.authorizer.Authorize(string(cl.Properties.Username), topic, write)
+}
+
+func (m *embeddedMQTT) Subscribe(filter string, handler func(topic string, payload []byte)) error {
+	m.nextID++
+	return m.server.Subscribe(filter, m.nextID, func(cl *mqtt.Client, sub packets.Subscription, pk packets.Packet) {
+		handler(pk.TopicName, pk.Payload)
+	})
+}
+
+func (m *embeddedMQTT) Publish(topic string, payload []byte, retained bool) error {
+	return m.server.Publish(topic, payload, retained, 0)
+}
+
+// 外部ブローカー
+type externalMQTT struct {
+	client paho.Client
+	subs   map[string]paho.MessageHandler
+	mu     sync.Mutex
+}
+
+func setupExternalMQTT() (MQTTClient, error) {
+	broker := os.Getenv("MQTT_BROKER_URL")
+	if broker == "" {
+		return nil, fmt.Errorf("MQTT_BROKER_URL is required when MQTT_MODE=external")
+	}
+
+	clientID := os.Getenv("MQTT_CLIENT_ID")
+	if clientID == "" {
+		clientID = "freeren-server"
+	}
+
+	m := &externalMQTT{subs: map[string]paho.MessageHandler{}}
+
+	opts := paho.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(clientID).
+		SetUsername(os.Getenv("MQTT_USERNAME")).
+		SetPassword(os.Getenv("MQTT_PASSWORD")).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetOnConnectHandler(m.resubscribe)
+
+	client := paho.NewClient(opts)
+	m.client = client
+	token := client.Connect()
+	if !token.WaitTimeout(10 * time.Second) {
+		return nil, fmt.Errorf("timed out connecting to MQTT broker %s", broker)
+	}
+	if err := token.Error(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Connected to MQTT broker %s", broker)
+	return m, nil
+}
+
+// 再接続時に購読をやり直す (クリーンセッションのため購読は失われる)
+func (m *externalMQTT) resubscribe(client paho.Client) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for filter, handler := range m.subs {
+		if token := client.Subscribe(filter, 1, handler); token.Wait() && token.Error() != nil {
+			log.Printf("Failed to resubscribe to %s: %v", filter, token.Error())
+		}
+	}
+}
+
+func (m *externalMQTT) Subscribe(filter string, handler func(topic string, payload []byte)) error {
+	msgHandler := func(_ paho.Client, msg paho.Message) {
+		handler(msg.Topic(), msg.Payload())
+	}
+
+	m.mu.Lock()
+	m.subs[filter] = msgHandler
+	m.mu.Unlock()
+
+	token := m.client.Subscribe(filter, 1, msgHandler)
+	token.Wait()
+	return token.Error()
+}
+
+func (m *externalMQTT) Publish(topic string, payload []byte, retained bool) error {
+	// 完了を待たずに送信する (ゲーム状態の更新をブロックしないため)
+	m.client.Publish(topic, 0, retained, payload)
+	return nil
+}
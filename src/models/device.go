@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// 登録済みのデバイス (コントローラー)
+type Device struct {
+	ID              string     `gorm:"type:varchar(64);primary_key" json:"id"`
+	Name            string     `gorm:"type:varchar(50)" json:"name"`
+	OwnerID         *UUID      `gorm:"type:uuid" json:"ownerId"`
+	Type            string     `gorm:"type:varchar(30)" json:"type"`
+	Secret          string     `gorm:"type:varchar(64)" json:"-"`
+	FirmwareVersion string     `gorm:"type:varchar(30)" json:"firmwareVersion"`
+	BatteryLevel    *int       `json:"batteryLevel"`
+	RSSI            *int       `gorm:"column:rssi" json:"rssi"`
+	ErrorCount      int        `json:"errorCount"`
+	LastSeenAt      *time.Time `json:"lastSeenAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+
+	// ファームウェアの段階的な配信に使うグループ
+	Group string `gorm:"column:device_group;type:varchar(30)" json:"group"`
+	// 直近のファームウェア更新の結果
+	LastUpdateVersion string     `gorm:"type:varchar(30)" json:"lastUpdateVersion"`
+	LastUpdateStatus  string     `gorm:"type:varchar(10)" json:"lastUpdateStatus"`
+	LastUpdateError   string     `json:"lastUpdateError"`
+	LastUpdateAt      *time.Time `json:"lastUpdateAt"`
+
+	// 入力プロファイル (ペアリング時にデバイスの種類の既定のプロファイルを割り当てる)
+	InputProfileID *int `json:"inputProfileId"`
+
+	// ペアリングしたプレイヤースロット (ペアリングしていない場合は空)
+	PairedPlayer string     `gorm:"type:varchar(30)" json:"pairedPlayer"`
+	PairedAt     *time.Time `json:"pairedAt"`
+}
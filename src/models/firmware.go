@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ファームウェアのイメージ
+type Firmware struct {
+	ID         int       `gorm:"primary_key" json:"id"`
+	DeviceType string    `gorm:"type:varchar(30);not null" json:"deviceType"`
+	Version    string    `gorm:"type:varchar(30);not null" json:"version"`
+	Checksum   string    `gorm:"type:varchar(64);not null" json:"checksum"` // SHA-256 (hex)
+	Size       int64     `gorm:"not null" json:"size"`
+	FilePath   string    `gorm:"type:varchar(255);not null" json:"-"`
+	Notes      string    `json:"notes"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ファームウェアをデバイスグループに配信する割合
+type FirmwareRollout struct {
+	ID         int       `gorm:"primary_key" json:"id"`
+	FirmwareID int       `gorm:"not null" json:"firmwareId"`
+	Group      string    `gorm:"column:device_group;type:varchar(30);not null" json:"group"`
+	Percentage int       `gorm:"not null" json:"percentage"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
@@ -12,5 +12,48 @@ type GameState struct {
 	Player2DF     int    `json:"player2Df"`
 	Player2Action string `json:"player2Action"`
 	Player2State  string `json:"player2State"`
-	Time		  int   `json:"time"`
+	Time          int    `json:"time"`
+	// 行動のジェスチャー認識の確からしさ (0〜1、デバイスで認識した場合は 1)
+	Player1Confidence float64 `json:"player1Confidence"`
+	Player2Confidence float64 `json:"player2Confidence"`
+	// 溜め攻撃の進み具合 (0〜100)
+	Player1Charge int `json:"player1Charge"`
+	Player2Charge int `json:"player2Charge"`
+	// キャラクタークラス
+	Player1Class string `json:"player1Class"`
+	Player2Class string `json:"player2Class"`
+	// ロビーのフェーズ ("waiting", "open", "ready_check", "pick", "countdown", "fighting")
+	Phase string `json:"phase"`
+	// 試合の特殊ルール
+	ArenaModifiers []string `json:"arenaModifiers"`
+	// 出現中のパワーアップ (なければ空)
+	PowerUp string `json:"powerUp"`
+	// 効いているパワーアップの効果 ("shield", "damage_boost")
+	Player1Buffs []string `json:"player1Buffs"`
+	Player2Buffs []string `json:"player2Buffs"`
+	// 属性 (属性なしの場合は空)
+	Player1Element string `json:"player1Element"`
+	Player2Element string `json:"player2Element"`
+	// 残りのストック、復活までの残り時間と復活後の無敵時間の残り (ミリ秒)
+	Player1Stocks         int   `json:"player1Stocks"`
+	Player2Stocks         int   `json:"player2Stocks"`
+	Player1RespawnMs      int64 `json:"player1RespawnMs"`
+	Player2RespawnMs      int64 `json:"player2RespawnMs"`
+	Player1InvulnerableMs int64 `json:"player1InvulnerableMs"`
+	Player2InvulnerableMs int64 `json:"player2InvulnerableMs"`
+}
+
+// ゲーム中に発生したイベント (攻撃、防御、カウントダウンなど)
+type GameEvent struct {
+	Type      string `json:"type"`
+	PlayerID  string `json:"playerId"`
+	TargetID  string `json:"targetId,omitempty"`
+	Value     int    `json:"value"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// WebSocketで送信するメッセージ (state か event のどちらか一方)
+type GameMessage struct {
+	State *GameState `json:"state,omitempty"`
+	Event *GameEvent `json:"event,omitempty"`
 }
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// IMUのサンプル (加速度は g、角速度は deg/s)
+type IMUSample struct {
+	T  int64   `json:"t"` // デバイスの時刻 (UNIXミリ秒)
+	AX float64 `json:"ax"`
+	AY float64 `json:"ay"`
+	AZ float64 `json:"az"`
+	GX float64 `json:"gx"`
+	GY float64 `json:"gy"`
+	GZ float64 `json:"gz"`
+}
+
+// IMUSamples JSONB のカラムに保存するサンプルの列
+type IMUSamples []IMUSample
+
+func (s IMUSamples) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (s *IMUSamples) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case nil:
+		*s = nil
+		return nil
+	}
+	return errors.New("unsupported type for IMUSamples")
+}
+
+// ラベル付きのジェスチャーのサンプル (認識の調整や学習に使う)
+type GestureSample struct {
+	ID         int        `gorm:"primary_key" json:"id"`
+	DeviceID   string     `gorm:"type:varchar(64);not null" json:"deviceId"`
+	UserID     *UUID      `gorm:"type:uuid" json:"userId"`
+	Label      string     `gorm:"type:varchar(30);not null" json:"label"`
+	Samples    IMUSamples `gorm:"type:jsonb;not null" json:"samples"`
+	DurationMs int64      `json:"durationMs"`
+	// 記録時の認識結果 (認識の精度の確認用)
+	RecognizedAction     string    `gorm:"type:varchar(30)" json:"recognizedAction"`
+	RecognizedConfidence float64   `json:"recognizedConfidence"`
+	CreatedAt            time.Time `json:"createdAt"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// InputMappings デバイスの入力 → ゲームのアクション
+type InputMappings map[string]string
+
+func (m InputMappings) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (m *InputMappings) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case nil:
+		*m = nil
+		return nil
+	}
+	return errors.New("unsupported type for InputMappings")
+}
+
+// デバイスの種類ごとの入力プロファイル
+type InputProfile struct {
+	ID         int           `gorm:"primary_key" json:"id"`
+	Name       string        `gorm:"type:varchar(50);not null" json:"name"`
+	DeviceType string        `gorm:"type:varchar(30);not null" json:"deviceType"`
+	Mappings   InputMappings `gorm:"type:jsonb;not null" json:"mappings"`
+	IsDefault  bool          `json:"isDefault"` // デバイスの種類でペアリング時に割り当てるプロファイル
+	CreatedAt  time.Time     `json:"createdAt"`
+	UpdatedAt  time.Time     `json:"updatedAt"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// ユーザーのロードアウト (スロットに参加した時に適用するクラス)
+type Loadout struct {
+	UserID    UUID      `gorm:"type:uuid;primary_key" json:"userId"`
+	Class     string    `gorm:"type:varchar(30);not null" json:"class"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
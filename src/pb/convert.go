@@ -0,0 +1,60 @@
+package pb
+
+import "md2s/models"
+
+// FromGameState models.GameState を変換
+func FromGameState(gameState models.GameState) *GameState {
+	return &GameState{
+		Player1Hp:     int32(gameState.Player1HP),
+		Player1Mp:     int32(gameState.Player1MP),
+		Player1Df:     int32(gameState.Player1DF),
+		Player1Action: gameState.Player1Action,
+		Player1State:  gameState.Player1State,
+		Player2Hp:     int32(gameState.Player2HP),
+		Player2Mp:     int32(gameState.Player2MP),
+		Player2Df:     int32(gameState.Player2DF),
+		Player2Action: gameState.Player2Action,
+		Player2State:  gameState.Player2State,
+		Time:          int32(gameState.Time),
+
+		Player1Confidence: gameState.Player1Confidence,
+		Player2Confidence: gameState.Player2Confidence,
+		Player1Charge:     int32(gameState.Player1Charge),
+		Player2Charge:     int32(gameState.Player2Charge),
+		Player1Class:      gameState.Player1Class,
+		Player2Class:      gameState.Player2Class,
+		Phase:             gameState.Phase,
+		ArenaModifiers:    gameState.ArenaModifiers,
+		PowerUp:           gameState.PowerUp,
+		Player1Buffs:      gameState.Player1Buffs,
+		Player2Buffs:      gameState.Player2Buffs,
+		Player1Element:    gameState.Player1Element,
+		Player2Element:    gameState.Player2Element,
+
+		Player1Stocks:         int32(gameState.Player1Stocks),
+		Player2Stocks:         int32(gameState.Player2Stocks),
+		Player1RespawnMs:      gameState.Player1RespawnMs,
+		Player2RespawnMs:      gameState.Player2RespawnMs,
+		Player1InvulnerableMs: gameState.Player1InvulnerableMs,
+		Player2InvulnerableMs: gameState.Player2InvulnerableMs,
+	}
+}
+
+// FromGameEvent models.GameEvent を変換
+func FromGameEvent(event models.GameEvent) *GameEvent {
+	return &GameEvent{
+		Type:      event.Type,
+		PlayerId:  event.PlayerID,
+		TargetId:  event.TargetID,
+		Value:     int32(event.Value),
+		Timestamp: event.Timestamp,
+	}
+}
+
+// FromGameMessage models.GameMessage を WatchResponse に変換
+func FromGameMessage(msg models.GameMessage) *WatchResponse {
+	if msg.Event != nil {
+		return &WatchResponse{Payload: &WatchResponse_Event{Event: FromGameEvent(*msg.Event)}}
+	}
+	return &WatchResponse{Payload: &WatchResponse_State{State: FromGameState(*msg.State)}}
+}
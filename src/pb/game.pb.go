@@ -0,0 +1,1192 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.1
+// 	protoc        (unknown)
+// source: game.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// models.GameState に対応
+type GameState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Player1Hp             int32    `protobuf:"varint,1,opt,name=player1_hp,json=player1Hp,proto3" json:"player1_hp,omitempty"`
+	Player1Mp             int32    `protobuf:"varint,2,opt,name=player1_mp,json=player1Mp,proto3" json:"player1_mp,omitempty"`
+	Player1Df             int32    `protobuf:"varint,3,opt,name=player1_df,json=player1Df,proto3" json:"player1_df,omitempty"`
+	Player1Action         string   `protobuf:"bytes,4,opt,name=player1_action,json=player1Action,proto3" json:"player1_action,omitempty"`
+	Player1State          string   `protobuf:"bytes,5,opt,name=player1_state,json=player1State,proto3" json:"player1_state,omitempty"`
+	Player2Hp             int32    `protobuf:"varint,6,opt,name=player2_hp,json=player2Hp,proto3" json:"player2_hp,omitempty"`
+	Player2Mp             int32    `protobuf:"varint,7,opt,name=player2_mp,json=player2Mp,proto3" json:"player2_mp,omitempty"`
+	Player2Df             int32    `protobuf:"varint,8,opt,name=player2_df,json=player2Df,proto3" json:"player2_df,omitempty"`
+	Player2Action         string   `protobuf:"bytes,9,opt,name=player2_action,json=player2Action,proto3" json:"player2_action,omitempty"`
+	Player2State          string   `protobuf:"bytes,10,opt,name=player2_state,json=player2State,proto3" json:"player2_state,omitempty"`
+	Time                  int32    `protobuf:"varint,11,opt,name=time,proto3" json:"time,omitempty"`
+	Player1Confidence     float64  `protobuf:"fixed64,12,opt,name=player1_confidence,json=player1Confidence,proto3" json:"player1_confidence,omitempty"`
+	Player2Confidence     float64  `protobuf:"fixed64,13,opt,name=player2_confidence,json=player2Confidence,proto3" json:"player2_confidence,omitempty"`
+	Player1Charge         int32    `protobuf:"varint,14,opt,name=player1_charge,json=player1Charge,proto3" json:"player1_charge,omitempty"`
+	Player2Charge         int32    `protobuf:"varint,15,opt,name=player2_charge,json=player2Charge,proto3" json:"player2_charge,omitempty"`
+	Player1Class          string   `protobuf:"bytes,16,opt,name=player1_class,json=player1Class,proto3" json:"player1_class,omitempty"`
+	Player2Class          string   `protobuf:"bytes,17,opt,name=player2_class,json=player2Class,proto3" json:"player2_class,omitempty"`
+	Phase                 string   `protobuf:"bytes,18,opt,name=phase,proto3" json:"phase,omitempty"`
+	ArenaModifiers        []string `protobuf:"bytes,19,rep,name=arena_modifiers,json=arenaModifiers,proto3" json:"arena_modifiers,omitempty"`
+	PowerUp               string   `protobuf:"bytes,20,opt,name=power_up,json=powerUp,proto3" json:"power_up,omitempty"`
+	Player1Buffs          []string `protobuf:"bytes,21,rep,name=player1_buffs,json=player1Buffs,proto3" json:"player1_buffs,omitempty"`
+	Player2Buffs          []string `protobuf:"bytes,22,rep,name=player2_buffs,json=player2Buffs,proto3" json:"player2_buffs,omitempty"`
+	Player1Element        string   `protobuf:"bytes,23,opt,name=player1_element,json=player1Element,proto3" json:"player1_element,omitempty"`
+	Player2Element        string   `protobuf:"bytes,24,opt,name=player2_element,json=player2Element,proto3" json:"player2_element,omitempty"`
+	Player1Stocks         int32    `protobuf:"varint,25,opt,name=player1_stocks,json=player1Stocks,proto3" json:"player1_stocks,omitempty"`
+	Player2Stocks         int32    `protobuf:"varint,26,opt,name=player2_stocks,json=player2Stocks,proto3" json:"player2_stocks,omitempty"`
+	Player1RespawnMs      int64    `protobuf:"varint,27,opt,name=player1_respawn_ms,json=player1RespawnMs,proto3" json:"player1_respawn_ms,omitempty"`
+	Player2RespawnMs      int64    `protobuf:"varint,28,opt,name=player2_respawn_ms,json=player2RespawnMs,proto3" json:"player2_respawn_ms,omitempty"`
+	Player1InvulnerableMs int64    `protobuf:"varint,29,opt,name=player1_invulnerable_ms,json=player1InvulnerableMs,proto3" json:"player1_invulnerable_ms,omitempty"`
+	Player2InvulnerableMs int64    `protobuf:"varint,30,opt,name=player2_invulnerable_ms,json=player2InvulnerableMs,proto3" json:"player2_invulnerable_ms,omitempty"`
+}
+
+func (x *GameState) Reset() {
+	*x = GameState{}
+	mi := &file_game_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameState) ProtoMessage() {}
+
+func (x *GameState) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GameState.ProtoReflect.Descriptor instead.
+func (*GameState) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GameState) GetPlayer1Hp() int32 {
+	if x != nil {
+		return x.Player1Hp
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer1Mp() int32 {
+	if x != nil {
+		return x.Player1Mp
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer1Df() int32 {
+	if x != nil {
+		return x.Player1Df
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer1Action() string {
+	if x != nil {
+		return x.Player1Action
+	}
+	return ""
+}
+
+func (x *GameState) GetPlayer1State() string {
+	if x != nil {
+		return x.Player1State
+	}
+	return ""
+}
+
+func (x *GameState) GetPlayer2Hp() int32 {
+	if x != nil {
+		return x.Player2Hp
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer2Mp() int32 {
+	if x != nil {
+		return x.Player2Mp
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer2Df() int32 {
+	if x != nil {
+		return x.Player2Df
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer2Action() string {
+	if x != nil {
+		return x.Player2Action
+	}
+	return ""
+}
+
+func (x *GameState) GetPlayer2State() string {
+	if x != nil {
+		return x.Player2State
+	}
+	return ""
+}
+
+func (x *GameState) GetTime() int32 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer1Confidence() float64 {
+	if x != nil {
+		return x.Player1Confidence
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer2Confidence() float64 {
+	if x != nil {
+		return x.Player2Confidence
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer1Charge() int32 {
+	if x != nil {
+		return x.Player1Charge
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer2Charge() int32 {
+	if x != nil {
+		return x.Player2Charge
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer1Class() string {
+	if x != nil {
+		return x.Player1Class
+	}
+	return ""
+}
+
+func (x *GameState) GetPlayer2Class() string {
+	if x != nil {
+		return x.Player2Class
+	}
+	return ""
+}
+
+func (x *GameState) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *GameState) GetArenaModifiers() []string {
+	if x != nil {
+		return x.ArenaModifiers
+	}
+	return nil
+}
+
+func (x *GameState) GetPowerUp() string {
+	if x != nil {
+		return x.PowerUp
+	}
+	return ""
+}
+
+func (x *GameState) GetPlayer1Buffs() []string {
+	if x != nil {
+		return x.Player1Buffs
+	}
+	return nil
+}
+
+func (x *GameState) GetPlayer2Buffs() []string {
+	if x != nil {
+		return x.Player2Buffs
+	}
+	return nil
+}
+
+func (x *GameState) GetPlayer1Element() string {
+	if x != nil {
+		return x.Player1Element
+	}
+	return ""
+}
+
+func (x *GameState) GetPlayer2Element() string {
+	if x != nil {
+		return x.Player2Element
+	}
+	return ""
+}
+
+func (x *GameState) GetPlayer1Stocks() int32 {
+	if x != nil {
+		return x.Player1Stocks
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer2Stocks() int32 {
+	if x != nil {
+		return x.Player2Stocks
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer1RespawnMs() int64 {
+	if x != nil {
+		return x.Player1RespawnMs
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer2RespawnMs() int64 {
+	if x != nil {
+		return x.Player2RespawnMs
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer1InvulnerableMs() int64 {
+	if x != nil {
+		return x.Player1InvulnerableMs
+	}
+	return 0
+}
+
+func (x *GameState) GetPlayer2InvulnerableMs() int64 {
+	if x != nil {
+		return x.Player2InvulnerableMs
+	}
+	return 0
+}
+
+// models.GameEvent に対応
+type GameEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type      string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	PlayerId  string `protobuf:"bytes,2,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	TargetId  string `protobuf:"bytes,3,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
+	Value     int32  `protobuf:"varint,4,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp int64  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (x *GameEvent) Reset() {
+	*x = GameEvent{}
+	mi := &file_game_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GameEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GameEvent) ProtoMessage() {}
+
+func (x *GameEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GameEvent.ProtoReflect.Descriptor instead.
+func (*GameEvent) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GameEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *GameEvent) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *GameEvent) GetTargetId() string {
+	if x != nil {
+		return x.TargetId
+	}
+	return ""
+}
+
+func (x *GameEvent) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *GameEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// デバイスからの入力 (POST /device/input と同じ内容)
+type DeviceInput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DeviceId string `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	Action   string `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+	State    string `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	// デバイスの時刻 (UNIXミリ秒)。時刻同期済みの場合はラグ補正に使う
+	Timestamp int64 `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// ボタンID・キーコードなどの生の入力 (入力プロファイルでアクションに変換する)
+	Input string `protobuf:"bytes,5,opt,name=input,proto3" json:"input,omitempty"`
+	// 振りの強さ (0〜1)。省略時は 1
+	Power *float64 `protobuf:"fixed64,6,opt,name=power,proto3,oneof" json:"power,omitempty"`
+	// この入力の属性。プレイヤーの属性と違う場合は切り替えのMPを消費して切り替える (省略時はプレイヤーの属性のまま)
+	Element string `protobuf:"bytes,7,opt,name=element,proto3" json:"element,omitempty"`
+}
+
+func (x *DeviceInput) Reset() {
+	*x = DeviceInput{}
+	mi := &file_game_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceInput) ProtoMessage() {}
+
+func (x *DeviceInput) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceInput.ProtoReflect.Descriptor instead.
+func (*DeviceInput) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DeviceInput) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *DeviceInput) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *DeviceInput) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *DeviceInput) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *DeviceInput) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *DeviceInput) GetPower() float64 {
+	if x != nil && x.Power != nil {
+		return *x.Power
+	}
+	return 0
+}
+
+func (x *DeviceInput) GetElement() string {
+	if x != nil {
+		return x.Element
+	}
+	return ""
+}
+
+// プレイヤーとしての参加
+type PlayerJoin struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId string `protobuf:"bytes,1,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	// ロードアウトを適用するユーザー (省略可)
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// user_id のユーザートークン (user_id を指定する場合は必須)
+	UserToken string `protobuf:"bytes,3,opt,name=user_token,json=userToken,proto3" json:"user_token,omitempty"`
+}
+
+func (x *PlayerJoin) Reset() {
+	*x = PlayerJoin{}
+	mi := &file_game_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayerJoin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayerJoin) ProtoMessage() {}
+
+func (x *PlayerJoin) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayerJoin.ProtoReflect.Descriptor instead.
+func (*PlayerJoin) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PlayerJoin) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *PlayerJoin) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PlayerJoin) GetUserToken() string {
+	if x != nil {
+		return x.UserToken
+	}
+	return ""
+}
+
+// プレイヤーからの入力
+type PlayerInput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId string `protobuf:"bytes,1,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	Action   string `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`
+}
+
+func (x *PlayerInput) Reset() {
+	*x = PlayerInput{}
+	mi := &file_game_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayerInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayerInput) ProtoMessage() {}
+
+func (x *PlayerInput) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayerInput.ProtoReflect.Descriptor instead.
+func (*PlayerInput) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PlayerInput) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *PlayerInput) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+type PlayRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Payload:
+	//	*PlayRequest_DeviceInput
+	//	*PlayRequest_PlayerJoin
+	//	*PlayRequest_PlayerInput
+	Payload isPlayRequest_Payload `protobuf_oneof:"payload"`
+}
+
+func (x *PlayRequest) Reset() {
+	*x = PlayRequest{}
+	mi := &file_game_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayRequest) ProtoMessage() {}
+
+func (x *PlayRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayRequest.ProtoReflect.Descriptor instead.
+func (*PlayRequest) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{5}
+}
+
+func (m *PlayRequest) GetPayload() isPlayRequest_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (x *PlayRequest) GetDeviceInput() *DeviceInput {
+	if x, ok := x.GetPayload().(*PlayRequest_DeviceInput); ok {
+		return x.DeviceInput
+	}
+	return nil
+}
+
+func (x *PlayRequest) GetPlayerJoin() *PlayerJoin {
+	if x, ok := x.GetPayload().(*PlayRequest_PlayerJoin); ok {
+		return x.PlayerJoin
+	}
+	return nil
+}
+
+func (x *PlayRequest) GetPlayerInput() *PlayerInput {
+	if x, ok := x.GetPayload().(*PlayRequest_PlayerInput); ok {
+		return x.PlayerInput
+	}
+	return nil
+}
+
+type isPlayRequest_Payload interface {
+	isPlayRequest_Payload()
+}
+
+type PlayRequest_DeviceInput struct {
+	DeviceInput *DeviceInput `protobuf:"bytes,1,opt,name=device_input,json=deviceInput,proto3,oneof"`
+}
+
+type PlayRequest_PlayerJoin struct {
+	PlayerJoin *PlayerJoin `protobuf:"bytes,2,opt,name=player_join,json=playerJoin,proto3,oneof"`
+}
+
+type PlayRequest_PlayerInput struct {
+	PlayerInput *PlayerInput `protobuf:"bytes,3,opt,name=player_input,json=playerInput,proto3,oneof"`
+}
+
+func (*PlayRequest_DeviceInput) isPlayRequest_Payload() {}
+
+func (*PlayRequest_PlayerJoin) isPlayRequest_Payload() {}
+
+func (*PlayRequest_PlayerInput) isPlayRequest_Payload() {}
+
+// 入力の処理結果
+type InputResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ok bool `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	// "fighting" などの処理結果、またはエラー内容
+	Status string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (x *InputResult) Reset() {
+	*x = InputResult{}
+	mi := &file_game_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InputResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InputResult) ProtoMessage() {}
+
+func (x *InputResult) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InputResult.ProtoReflect.Descriptor instead.
+func (*InputResult) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *InputResult) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *InputResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type PlayResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Payload:
+	//	*PlayResponse_Result
+	//	*PlayResponse_State
+	//	*PlayResponse_Event
+	Payload isPlayResponse_Payload `protobuf_oneof:"payload"`
+}
+
+func (x *PlayResponse) Reset() {
+	*x = PlayResponse{}
+	mi := &file_game_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayResponse) ProtoMessage() {}
+
+func (x *PlayResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayResponse.ProtoReflect.Descriptor instead.
+func (*PlayResponse) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{7}
+}
+
+func (m *PlayResponse) GetPayload() isPlayResponse_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (x *PlayResponse) GetResult() *InputResult {
+	if x, ok := x.GetPayload().(*PlayResponse_Result); ok {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *PlayResponse) GetState() *GameState {
+	if x, ok := x.GetPayload().(*PlayResponse_State); ok {
+		return x.State
+	}
+	return nil
+}
+
+func (x *PlayResponse) GetEvent() *GameEvent {
+	if x, ok := x.GetPayload().(*PlayResponse_Event); ok {
+		return x.Event
+	}
+	return nil
+}
+
+type isPlayResponse_Payload interface {
+	isPlayResponse_Payload()
+}
+
+type PlayResponse_Result struct {
+	Result *InputResult `protobuf:"bytes,1,opt,name=result,proto3,oneof"`
+}
+
+type PlayResponse_State struct {
+	State *GameState `protobuf:"bytes,2,opt,name=state,proto3,oneof"`
+}
+
+type PlayResponse_Event struct {
+	Event *GameEvent `protobuf:"bytes,3,opt,name=event,proto3,oneof"`
+}
+
+func (*PlayResponse_Result) isPlayResponse_Payload() {}
+
+func (*PlayResponse_State) isPlayResponse_Payload() {}
+
+func (*PlayResponse_Event) isPlayResponse_Payload() {}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_game_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{8}
+}
+
+type WatchResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Payload:
+	//	*WatchResponse_State
+	//	*WatchResponse_Event
+	Payload isWatchResponse_Payload `protobuf_oneof:"payload"`
+}
+
+func (x *WatchResponse) Reset() {
+	*x = WatchResponse{}
+	mi := &file_game_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResponse) ProtoMessage() {}
+
+func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{9}
+}
+
+func (m *WatchResponse) GetPayload() isWatchResponse_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetState() *GameState {
+	if x, ok := x.GetPayload().(*WatchResponse_State); ok {
+		return x.State
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetEvent() *GameEvent {
+	if x, ok := x.GetPayload().(*WatchResponse_Event); ok {
+		return x.Event
+	}
+	return nil
+}
+
+type isWatchResponse_Payload interface {
+	isWatchResponse_Payload()
+}
+
+type WatchResponse_State struct {
+	State *GameState `protobuf:"bytes,1,opt,name=state,proto3,oneof"`
+}
+
+type WatchResponse_Event struct {
+	Event *GameEvent `protobuf:"bytes,2,opt,name=event,proto3,oneof"`
+}
+
+func (*WatchResponse_State) isWatchResponse_Payload() {}
+
+func (*WatchResponse_Event) isWatchResponse_Payload() {}
+
+var File_game_proto protoreflect.FileDescriptor
+
+var file_game_proto_rawDesc = []byte{
+	0x0a, 0x0a, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0f, 0x66, 0x72,
+	0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x22, 0xf7, 0x08,
+	0x0a, 0x09, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x5f, 0x68, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x09, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x48, 0x70, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x6c,
+	0x61, 0x79, 0x65, 0x72, 0x31, 0x5f, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09,
+	0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x4d, 0x70, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x31, 0x5f, 0x64, 0x66, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x70,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x44, 0x66, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x31, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0d, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x23, 0x0a, 0x0d, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x5f,
+	0x68, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x32, 0x48, 0x70, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x5f, 0x6d,
+	0x70, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32,
+	0x4d, 0x70, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x5f, 0x64, 0x66,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x44,
+	0x66, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x5f, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x70, 0x6c, 0x61, 0x79, 0x65,
+	0x72, 0x32, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x32, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x74, 0x69, 0x6d,
+	0x65, 0x12, 0x2d, 0x0a, 0x12, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x5f, 0x63, 0x6f, 0x6e,
+	0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x01, 0x52, 0x11, 0x70,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65,
+	0x12, 0x2d, 0x0a, 0x12, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x5f, 0x63, 0x6f, 0x6e, 0x66,
+	0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x01, 0x52, 0x11, 0x70, 0x6c,
+	0x61, 0x79, 0x65, 0x72, 0x32, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x12,
+	0x25, 0x0a, 0x0e, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x5f, 0x63, 0x68, 0x61, 0x72, 0x67,
+	0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31,
+	0x43, 0x68, 0x61, 0x72, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x32, 0x5f, 0x63, 0x68, 0x61, 0x72, 0x67, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d,
+	0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x43, 0x68, 0x61, 0x72, 0x67, 0x65, 0x12, 0x23, 0x0a,
+	0x0d, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x5f, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x10,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x43, 0x6c, 0x61,
+	0x73, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x5f, 0x63, 0x6c,
+	0x61, 0x73, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x6c, 0x61, 0x79, 0x65,
+	0x72, 0x32, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65,
+	0x18, 0x12, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x68, 0x61, 0x73, 0x65, 0x12, 0x27, 0x0a,
+	0x0f, 0x61, 0x72, 0x65, 0x6e, 0x61, 0x5f, 0x6d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65, 0x72, 0x73,
+	0x18, 0x13, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x72, 0x65, 0x6e, 0x61, 0x4d, 0x6f, 0x64,
+	0x69, 0x66, 0x69, 0x65, 0x72, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x5f,
+	0x75, 0x70, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x55,
+	0x70, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x5f, 0x62, 0x75, 0x66,
+	0x66, 0x73, 0x18, 0x15, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x31, 0x42, 0x75, 0x66, 0x66, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x32, 0x5f, 0x62, 0x75, 0x66, 0x66, 0x73, 0x18, 0x16, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x70,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x42, 0x75, 0x66, 0x66, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x70,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x5f, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x17,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x45, 0x6c, 0x65,
+	0x6d, 0x65, 0x6e, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x5f,
+	0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x18, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x70,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x25, 0x0a,
+	0x0e, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x5f, 0x73, 0x74, 0x6f, 0x63, 0x6b, 0x73, 0x18,
+	0x19, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x53, 0x74,
+	0x6f, 0x63, 0x6b, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x5f,
+	0x73, 0x74, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x70, 0x6c,
+	0x61, 0x79, 0x65, 0x72, 0x32, 0x53, 0x74, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x2c, 0x0a, 0x12, 0x70,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x31, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x5f, 0x6d,
+	0x73, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x31,
+	0x52, 0x65, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x4d, 0x73, 0x12, 0x2c, 0x0a, 0x12, 0x70, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x32, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x61, 0x77, 0x6e, 0x5f, 0x6d, 0x73, 0x18,
+	0x1c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x52, 0x65,
+	0x73, 0x70, 0x61, 0x77, 0x6e, 0x4d, 0x73, 0x12, 0x36, 0x0a, 0x17, 0x70, 0x6c, 0x61, 0x79, 0x65,
+	0x72, 0x31, 0x5f, 0x69, 0x6e, 0x76, 0x75, 0x6c, 0x6e, 0x65, 0x72, 0x61, 0x62, 0x6c, 0x65, 0x5f,
+	0x6d, 0x73, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x15, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x31, 0x49, 0x6e, 0x76, 0x75, 0x6c, 0x6e, 0x65, 0x72, 0x61, 0x62, 0x6c, 0x65, 0x4d, 0x73, 0x12,
+	0x36, 0x0a, 0x17, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x5f, 0x69, 0x6e, 0x76, 0x75, 0x6c,
+	0x6e, 0x65, 0x72, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x6d, 0x73, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x15, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x32, 0x49, 0x6e, 0x76, 0x75, 0x6c, 0x6e, 0x65,
+	0x72, 0x61, 0x62, 0x6c, 0x65, 0x4d, 0x73, 0x22, 0x8d, 0x01, 0x0a, 0x09, 0x47, 0x61, 0x6d, 0x65,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c,
+	0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0xcb, 0x01, 0x0a, 0x0b, 0x44, 0x65, 0x76, 0x69,
+	0x63, 0x65, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x65, 0x76, 0x69, 0x63,
+	0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x65, 0x76, 0x69,
+	0x63, 0x65, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05,
+	0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61,
+	0x74, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x19, 0x0a, 0x05, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x05, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x88, 0x01,
+	0x01, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x42, 0x08, 0x0a, 0x06, 0x5f,
+	0x70, 0x6f, 0x77, 0x65, 0x72, 0x22, 0x61, 0x0a, 0x0a, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x4a,
+	0x6f, 0x69, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64,
+	0x12, 0x17, 0x0a, 0x07, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x75, 0x73, 0x65,
+	0x72, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x75,
+	0x73, 0x65, 0x72, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x42, 0x0a, 0x0b, 0x50, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6c, 0x61, 0x79, 0x65,
+	0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xde, 0x01, 0x0a,
+	0x0b, 0x50, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x41, 0x0a, 0x0c,
+	0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x66, 0x72, 0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e, 0x67, 0x61, 0x6d,
+	0x65, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x49, 0x6e, 0x70, 0x75, 0x74,
+	0x48, 0x00, 0x52, 0x0b, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12,
+	0x3e, 0x0a, 0x0b, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x6a, 0x6f, 0x69, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x66, 0x72, 0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e, 0x67,
+	0x61, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x4a, 0x6f, 0x69,
+	0x6e, 0x48, 0x00, 0x52, 0x0a, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x4a, 0x6f, 0x69, 0x6e, 0x12,
+	0x41, 0x0a, 0x0c, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x5f, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x66, 0x72, 0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e,
+	0x67, 0x61, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x6e,
+	0x70, 0x75, 0x74, 0x48, 0x00, 0x52, 0x0b, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x6e, 0x70,
+	0x75, 0x74, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x35, 0x0a,
+	0x0b, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02,
+	0x6f, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x6f, 0x6b, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x22, 0xb9, 0x01, 0x0a, 0x0c, 0x50, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x66, 0x72, 0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e,
+	0x67, 0x61, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x48, 0x00, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x32, 0x0a,
+	0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x66,
+	0x72, 0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47,
+	0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x32, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x66, 0x72, 0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e,
+	0x76, 0x31, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x05,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x22, 0x0e, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x22, 0x82, 0x01, 0x0a, 0x0d, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x32, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x66, 0x72, 0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e, 0x67, 0x61, 0x6d, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x48, 0x00, 0x52,
+	0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x32, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x66, 0x72, 0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e,
+	0x67, 0x61, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x48, 0x00, 0x52, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x32, 0xa0, 0x01, 0x0a, 0x0b, 0x47, 0x61, 0x6d, 0x65, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x47, 0x0a, 0x04, 0x50, 0x6c, 0x61, 0x79, 0x12, 0x1c, 0x2e,
+	0x66, 0x72, 0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e,
+	0x50, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x66, 0x72,
+	0x65, 0x65, 0x72, 0x65, 0x6e, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6c,
+	0x61, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x12, 0x48,
+	0x0a, 0x05, 0x57, 0x61, 0x74, 0x63, 0x68, 0x12, 0x1d, 0x2e, 0x66, 0x72, 0x65, 0x65, 0x72, 0x65,
+	0x6e, 0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x66, 0x72, 0x65, 0x65, 0x72, 0x65, 0x6e,
+	0x2e, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x42, 0x0c, 0x5a, 0x0a, 0x6d, 0x64, 0x32, 0x73,
+	0x2f, 0x70, 0x62, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_game_proto_rawDescOnce sync.Once
+	file_game_proto_rawDescData = file_game_proto_rawDesc
+)
+
+func file_game_proto_rawDescGZIP() []byte {
+	file_game_proto_rawDescOnce.Do(func() {
+		file_game_proto_rawDescData = protoimpl.X.CompressGZIP(file_game_proto_rawDescData)
+	})
+	return file_game_proto_rawDescData
+}
+
+var file_game_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_game_proto_goTypes = []any{
+	(*GameState)(nil),     // 0: freeren.game.v1.GameState
+	(*GameEvent)(nil),     // 1: freeren.game.v1.GameEvent
+	(*DeviceInput)(nil),   // 2: freeren.game.v1.DeviceInput
+	(*PlayerJoin)(nil),    // 3: freeren.game.v1.PlayerJoin
+	(*PlayerInput)(nil),   // 4: freeren.game.v1.PlayerInput
+	(*PlayRequest)(nil),   // 5: freeren.game.v1.PlayRequest
+	(*InputResult)(nil),   // 6: freeren.game.v1.InputResult
+	(*PlayResponse)(nil),  // 7: freeren.game.v1.PlayResponse
+	(*WatchRequest)(nil),  // 8: freeren.game.v1.WatchRequest
+	(*WatchResponse)(nil), // 9: freeren.game.v1.WatchResponse
+}
+var file_game_proto_depIdxs = []int32{
+	2,  // 0: freeren.game.v1.PlayRequest.device_input:type_name -> freeren.game.v1.DeviceInput
+	3,  // 1: freeren.game.v1.PlayRequest.player_join:type_name -> freeren.game.v1.PlayerJoin
+	4,  // 2: freeren.game.v1.PlayRequest.player_input:type_name -> freeren.game.v1.PlayerInput
+	6,  // 3: freeren.game.v1.PlayResponse.result:type_name -> freeren.game.v1.InputResult
+	0,  // 4: freeren.game.v1.PlayResponse.state:type_name -> freeren.game.v1.GameState
+	1,  // 5: freeren.game.v1.PlayResponse.event:type_name -> freeren.game.v1.GameEvent
+	0,  // 6: freeren.game.v1.WatchResponse.state:type_name -> freeren.game.v1.GameState
+	1,  // 7: freeren.game.v1.WatchResponse.event:type_name -> freeren.game.v1.GameEvent
+	5,  // 8: freeren.game.v1.GameService.Play:input_type -> freeren.game.v1.PlayRequest
+	8,  // 9: freeren.game.v1.GameService.Watch:input_type -> freeren.game.v1.WatchRequest
+	7,  // 10: freeren.game.v1.GameService.Play:output_type -> freeren.game.v1.PlayResponse
+	9,  // 11: freeren.game.v1.GameService.Watch:output_type -> freeren.game.v1.WatchResponse
+	10, // [10:12] is the sub-list for method output_type
+	8,  // [8:10] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_game_proto_init() }
+func file_game_proto_init() {
+	if File_game_proto != nil {
+		return
+	}
+	file_game_proto_msgTypes[2].OneofWrappers = []any{}
+	file_game_proto_msgTypes[5].OneofWrappers = []any{
+		(*PlayRequest_DeviceInput)(nil),
+		(*PlayRequest_PlayerJoin)(nil),
+		(*PlayRequest_PlayerInput)(nil),
+	}
+	file_game_proto_msgTypes[7].OneofWrappers = []any{
+		(*PlayResponse_Result)(nil),
+		(*PlayResponse_State)(nil),
+		(*PlayResponse_Event)(nil),
+	}
+	file_game_proto_msgTypes[9].OneofWrappers = []any{
+		(*WatchResponse_State)(nil),
+		(*WatchResponse_Event)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_game_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_game_proto_goTypes,
+		DependencyIndexes: file_game_proto_depIdxs,
+		MessageInfos:      file_game_proto_msgTypes,
+	}.Build()
+	File_game_proto = out.File
+	file_game_proto_rawDesc = nil
+	file_game_proto_goTypes = nil
+	file_game_proto_depIdxs = nil
+}
@@ -0,0 +1,164 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: game.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GameService_Play_FullMethodName  = "/freeren.game.v1.GameService/Play"
+	GameService_Watch_FullMethodName = "/freeren.game.v1.GameService/Watch"
+)
+
+// GameServiceClient is the client API for GameService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// デバイス・プレイヤー・観戦者向けのゲームAPI
+type GameServiceClient interface {
+	// コントローラーやプレイヤーの入力を送り、結果・ゲーム状態・イベントを受け取る
+	Play(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[PlayRequest, PlayResponse], error)
+	// ゲーム状態とイベントを購読する (観戦用)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error)
+}
+
+type gameServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGameServiceClient(cc grpc.ClientConnInterface) GameServiceClient {
+	return &gameServiceClient{cc}
+}
+
+func (c *gameServiceClient) Play(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[PlayRequest, PlayResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GameService_ServiceDesc.Streams[0], GameService_Play_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PlayRequest, PlayResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GameService_PlayClient = grpc.BidiStreamingClient[PlayRequest, PlayResponse]
+
+func (c *gameServiceClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GameService_ServiceDesc.Streams[1], GameService_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GameService_WatchClient = grpc.ServerStreamingClient[WatchResponse]
+
+// GameServiceServer is the server API for GameService service.
+// All implementations must embed UnimplementedGameServiceServer
+// for forward compatibility.
+//
+// デバイス・プレイヤー・観戦者向けのゲームAPI
+type GameServiceServer interface {
+	// コントローラーやプレイヤーの入力を送り、結果・ゲーム状態・イベントを受け取る
+	Play(grpc.BidiStreamingServer[PlayRequest, PlayResponse]) error
+	// ゲーム状態とイベントを購読する (観戦用)
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error
+	mustEmbedUnimplementedGameServiceServer()
+}
+
+// UnimplementedGameServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGameServiceServer struct{}
+
+func (UnimplementedGameServiceServer) Play(grpc.BidiStreamingServer[PlayRequest, PlayResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Play not implemented")
+}
+func (UnimplementedGameServiceServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedGameServiceServer) mustEmbedUnimplementedGameServiceServer() {}
+func (UnimplementedGameServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeGameServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GameServiceServer will
+// result in compilation errors.
+type UnsafeGameServiceServer interface {
+	mustEmbedUnimplementedGameServiceServer()
+}
+
+func RegisterGameServiceServer(s grpc.ServiceRegistrar, srv GameServiceServer) {
+	// If the following call pancis, it indicates UnimplementedGameServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GameService_ServiceDesc, srv)
+}
+
+func _GameService_Play_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GameServiceServer).Play(&grpc.GenericServerStream[PlayRequest, PlayResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GameService_PlayServer = grpc.BidiStreamingServer[PlayRequest, PlayResponse]
+
+func _GameService_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GameServiceServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GameService_WatchServer = grpc.ServerStreamingServer[WatchResponse]
+
+// GameService_ServiceDesc is the grpc.ServiceDesc for GameService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GameService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "freeren.game.v1.GameService",
+	HandlerType: (*GameServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Play",
+			Handler:       _GameService_Play_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _GameService_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "game.proto",
+}
@@ -1,5 +1,54 @@
 package repositorys
 
-import "md2s/infra"
+import (
+	"errors"
+	"log"
+	"md2s/infra"
+	"sync"
+	"time"
 
-var db=infra.SetupDB()
\ No newline at end of file
+	"gorm.io/gorm"
+)
+
+// 接続に失敗した後、再接続を試すまでの間隔
+const reconnectInterval = 10 * time.Second
+
+// ErrNoDatabase データベースが設定されていない (メモリ上に保持できないデータを扱う場合)
+var ErrNoDatabase = errors.New("database is not configured")
+
+var (
+	database      *gorm.DB
+	dbErr         error
+	dbConnectedAt time.Time
+	dbMu          sync.Mutex
+)
+
+// Enabled データベースを使うかどうか
+// 接続先が設定されていない場合、デバイスの登録情報と入力プロファイルはメモリ上に保持する
+func Enabled() bool {
+	return infra.DBConfigured()
+}
+
+// 最初に使う時にデータベースへ接続する (失敗した場合はしばらくしてから接続し直す)
+func conn() (*gorm.DB, error) {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+
+	if database != nil {
+		return database, nil
+	}
+	if !Enabled() {
+		return nil, ErrNoDatabase
+	}
+	if dbErr != nil && time.Since(dbConnectedAt) < reconnectInterval {
+		return nil, dbErr
+	}
+
+	dbConnectedAt = time.Now()
+	database, dbErr = infra.SetupDB()
+	if dbErr != nil {
+		log.Printf("Failed to set up database: %v", dbErr)
+		return nil, dbErr
+	}
+	return database, nil
+}
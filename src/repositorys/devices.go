@@ -0,0 +1,211 @@
+package repositorys
+
+import (
+	"md2s/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+func GetDevices() ([]models.Device, error) {
+	if !Enabled() {
+		return memoryGetDevices(), nil
+	}
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []models.Device
+
+	query := db.Table("devices").Order("id")
+	result := query.Find(&devices)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return devices, nil
+}
+
+func GetDevice(deviceID string) (*models.Device, error) {
+	if !Enabled() {
+		return memoryGetDevice(deviceID)
+	}
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var device models.Device
+
+	query := db.Table("devices").Where("id = ?", deviceID)
+	result := query.First(&device)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &device, nil
+}
+
+// 名前・所有者・種類・グループを登録 (既に存在する場合は更新)
+func SaveDevice(device *models.Device) error {
+	if !Enabled() {
+		memorySaveDevice(device)
+		return nil
+	}
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	result := db.Table("devices").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "owner_id", "type", "device_group"}),
+	}).Select("id", "name", "owner_id", "type", "device_group").Create(device)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func DeleteDevice(deviceID string) error {
+	if !Enabled() {
+		memoryDeleteDevice(deviceID)
+		return nil
+	}
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	result := db.Table("devices").Where("id = ?", deviceID).Delete(&models.Device{})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// 指定したカラムを更新 (デバイスが未登録の場合は作成)
+func UpsertDeviceColumns(deviceID string, values map[string]interface{}) error {
+	if !Enabled() {
+		memoryUpsertDeviceColumns(deviceID, values)
+		return nil
+	}
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+
+	row := map[string]interface{}{"id": deviceID}
+	for column, value := range values {
+		row[column] = value
+	}
+
+	result := db.Table("devices").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(row)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func TouchDevice(deviceID string, lastSeenAt time.Time) error {
+	return UpsertDeviceColumns(deviceID, map[string]interface{}{"last_seen_at": lastSeenAt})
+}
+
+// シークレットが発行されているデバイスを取得
+func GetDeviceSecrets() (map[string]string, error) {
+	if !Enabled() {
+		return memoryGetDeviceSecrets(), nil
+	}
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []models.Device
+
+	query := db.Table("devices").Select("id", "secret").Where("secret IS NOT NULL AND secret <> ''")
+	result := query.Find(&devices)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	secrets := make(map[string]string, len(devices))
+	for _, device := range devices {
+		secrets[device.ID] = device.Secret
+	}
+	return secrets, nil
+}
+
+func ClearDeviceSecret(deviceID string) error {
+	if !Enabled() {
+		memoryUpsertDeviceColumns(deviceID, map[string]interface{}{"secret": ""})
+		return nil
+	}
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	result := db.Table("devices").Where("id = ?", deviceID).Update("secret", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// プレイヤースロットにペアリングしているデバイスを取得
+func GetDeviceBindings() ([]models.Device, error) {
+	if !Enabled() {
+		return memoryGetDeviceBindings(), nil
+	}
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []models.Device
+
+	query := db.Table("devices").Select("id", "paired_player", "paired_at").Where("paired_player IS NOT NULL AND paired_player <> ''")
+	result := query.Find(&devices)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return devices, nil
+}
+
+// デバイスをプレイヤースロットにペアリング (未登録の場合は作成)
+func SaveDeviceBinding(deviceID, playerID string, pairedAt time.Time) error {
+	return UpsertDeviceColumns(deviceID, map[string]interface{}{"paired_player": playerID, "paired_at": pairedAt})
+}
+
+// デバイスのペアリングを解除 (未登録の場合は何もしない)
+func ClearDeviceBinding(deviceID string) error {
+	if !Enabled() {
+		memoryClearDeviceBinding(deviceID)
+		return nil
+	}
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	result := db.Table("devices").Where("id = ?", deviceID).Updates(map[string]interface{}{"paired_player": nil, "paired_at": nil})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
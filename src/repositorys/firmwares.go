@@ -0,0 +1,156 @@
+package repositorys
+
+import (
+	"md2s/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+func GetFirmwares() ([]models.Firmware, error) {
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var firmwares []models.Firmware
+
+	query := db.Table("firmwares").Order("device_type, created_at DESC")
+	result := query.Find(&firmwares)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return firmwares, nil
+}
+
+func GetFirmware(firmwareID int) (*models.Firmware, error) {
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var firmware models.Firmware
+
+	query := db.Table("firmwares").Where("id = ?", firmwareID)
+	result := query.First(&firmware)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &firmware, nil
+}
+
+// デバイスの種類とバージョンでファームウェアを取得
+func GetFirmwareByVersion(deviceType, version string) (*models.Firmware, error) {
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var firmware models.Firmware
+
+	query := db.Table("firmwares").Where("device_type = ? AND version = ?", deviceType, version)
+	result := query.First(&firmware)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &firmware, nil
+}
+
+func CreateFirmware(firmware *models.Firmware) error {
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	result := db.Table("firmwares").Omit("created_at").Create(firmware)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func DeleteFirmware(firmwareID int) error {
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	result := db.Table("firmwares").Where("id = ?", firmwareID).Delete(&models.Firmware{})
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func GetFirmwareRollouts(firmwareID int) ([]models.FirmwareRollout, error) {
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var rollouts []models.FirmwareRollout
+
+	query := db.Table("firmware_rollouts").Where("firmware_id = ?", firmwareID).Order("device_group")
+	result := query.Find(&rollouts)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return rollouts, nil
+}
+
+// デバイスの種類とグループに配信されているファームウェアと配信割合を取得
+func GetRolledOutFirmwares(deviceType, group string) ([]models.Firmware, map[int]int, error) {
+	db, err := conn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []struct {
+		models.Firmware
+		Percentage int
+	}
+
+	query := db.Table("firmwares").
+		Select("firmwares.*, firmware_rollouts.percentage").
+		Joins("JOIN firmware_rollouts ON firmware_rollouts.firmware_id = firmwares.id").
+		Where("firmwares.device_type = ? AND firmware_rollouts.device_group = ? AND firmware_rollouts.percentage > 0", deviceType, group)
+	result := query.Find(&rows)
+
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+
+	firmwares := make([]models.Firmware, 0, len(rows))
+	percentages := make(map[int]int, len(rows))
+	for _, row := range rows {
+		firmwares = append(firmwares, row.Firmware)
+		percentages[row.ID] = row.Percentage
+	}
+	return firmwares, percentages, nil
+}
+
+// 配信割合を設定 (既に存在する場合は更新)
+func SaveFirmwareRollout(rollout *models.FirmwareRollout) error {
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	rollout.UpdatedAt = time.Now()
+	result := db.Table("firmware_rollouts").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "firmware_id"}, {Name: "device_group"}},
+		DoUpdates: clause.AssignmentColumns([]string{"percentage", "updated_at"}),
+	}).Create(rollout)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
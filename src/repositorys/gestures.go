@@ -0,0 +1,71 @@
+package repositorys
+
+import (
+	"md2s/models"
+)
+
+// ジェスチャーのサンプルを取得 (label, deviceID が空の場合は絞り込まない)
+func GetGestureSamples(label, deviceID string) ([]models.GestureSample, error) {
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []models.GestureSample
+
+	query := db.Table("gesture_samples").Order("id")
+	if label != "" {
+		query = query.Where("label = ?", label)
+	}
+	if deviceID != "" {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	result := query.Find(&samples)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return samples, nil
+}
+
+func CreateGestureSample(sample *models.GestureSample) error {
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	result := db.Table("gesture_samples").Omit("created_at").Create(sample)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// ラベルを変更 (サンプルが存在しない場合は false)
+func UpdateGestureSampleLabel(sampleID int, label string) (bool, error) {
+	db, err := conn()
+	if err != nil {
+		return false, err
+	}
+
+	result := db.Table("gesture_samples").Where("id = ?", sampleID).Update("label", label)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// サンプルを削除 (サンプルが存在しない場合は false)
+func DeleteGestureSample(sampleID int) (bool, error) {
+	db, err := conn()
+	if err != nil {
+		return false, err
+	}
+
+	result := db.Table("gesture_samples").Where("id = ?", sampleID).Delete(&models.GestureSample{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
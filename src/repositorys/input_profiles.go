@@ -0,0 +1,159 @@
+package repositorys
+
+import (
+	"md2s/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func GetInputProfiles() ([]models.InputProfile, error) {
+	if !Enabled() {
+		return memoryGetInputProfiles(), nil
+	}
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []models.InputProfile
+
+	query := db.Table("input_profiles").Order("device_type, name")
+	result := query.Find(&profiles)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return profiles, nil
+}
+
+func GetInputProfile(profileID int) (*models.InputProfile, error) {
+	if !Enabled() {
+		return memoryGetInputProfile(func(p *models.InputProfile) bool {
+			return p.ID == profileID
+		})
+	}
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var profile models.InputProfile
+
+	query := db.Table("input_profiles").Where("id = ?", profileID)
+	result := query.First(&profile)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &profile, nil
+}
+
+// デバイスの種類の既定のプロファイルを取得
+func GetDefaultInputProfile(deviceType string) (*models.InputProfile, error) {
+	if !Enabled() {
+		return memoryGetInputProfile(func(p *models.InputProfile) bool {
+			return p.DeviceType == deviceType && p.IsDefault
+		})
+	}
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var profile models.InputProfile
+
+	query := db.Table("input_profiles").Where("device_type = ? AND is_default", deviceType)
+	result := query.First(&profile)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &profile, nil
+}
+
+// プロファイルを作成・更新 (既定のプロファイルにした場合、同じ種類の他のプロファイルは既定でなくなる)
+func SaveInputProfile(profile *models.InputProfile) error {
+	if !Enabled() {
+		return memorySaveInputProfile(profile)
+	}
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if profile.IsDefault {
+			result := tx.Table("input_profiles").
+				Where("device_type = ? AND id <> ?", profile.DeviceType, profile.ID).
+				Update("is_default", false)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		profile.UpdatedAt = time.Now()
+		if profile.ID == 0 {
+			return tx.Table("input_profiles").Omit("created_at").Create(profile).Error
+		}
+		result := tx.Table("input_profiles").Where("id = ?", profile.ID).
+			Select("name", "device_type", "mappings", "is_default", "updated_at").Updates(profile)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func DeleteInputProfile(profileID int) (bool, error) {
+	if !Enabled() {
+		return memoryDeleteInputProfile(profileID), nil
+	}
+	db, err := conn()
+	if err != nil {
+		return false, err
+	}
+
+	result := db.Table("input_profiles").Where("id = ?", profileID).Delete(&models.InputProfile{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// デバイスに割り当てられているプロファイルの対応表を取得 (デバイスID → 対応表)
+func GetDeviceInputMappings() (map[string]models.InputMappings, error) {
+	if !Enabled() {
+		return memoryGetDeviceInputMappings(), nil
+	}
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		ID       string
+		Mappings models.InputMappings
+	}
+
+	query := db.Table("devices").
+		Select("devices.id, input_profiles.mappings").
+		Joins("JOIN input_profiles ON input_profiles.id = devices.input_profile_id")
+	result := query.Find(&rows)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	mappings := make(map[string]models.InputMappings, len(rows))
+	for _, row := range rows {
+		mappings[row.ID] = row.Mappings
+	}
+	return mappings, nil
+}
@@ -0,0 +1,42 @@
+package repositorys
+
+import (
+	"md2s/models"
+
+	"gorm.io/gorm/clause"
+)
+
+func GetLoadout(userID string) (*models.Loadout, error) {
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	var loadout models.Loadout
+
+	query := db.Table("loadouts").Where("user_id = ?", userID)
+	result := query.First(&loadout)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &loadout, nil
+}
+
+// ロードアウトを保存 (既に存在する場合は更新)
+func SaveLoadout(loadout *models.Loadout) error {
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
+	result := db.Table("loadouts").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"class", "updated_at"}),
+	}).Create(loadout)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
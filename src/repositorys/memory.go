@@ -0,0 +1,265 @@
+package repositorys
+
+import (
+	"md2s/models"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// データベースが設定されていない場合に使うメモリ上の保存先 (再起動すると消える)
+var memory = struct {
+	sync.Mutex
+	devices       map[string]*models.Device
+	profiles      map[int]*models.InputProfile
+	nextProfileID int
+}{
+	devices:       map[string]*models.Device{},
+	profiles:      map[int]*models.InputProfile{},
+	nextProfileID: 1,
+}
+
+func memoryGetDevices() []models.Device {
+	memory.Lock()
+	defer memory.Unlock()
+
+	devices := make([]models.Device, 0, len(memory.devices))
+	for _, device := range memory.devices {
+		devices = append(devices, *device)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].ID < devices[j].ID
+	})
+	return devices
+}
+
+func memoryGetDevice(deviceID string) (*models.Device, error) {
+	memory.Lock()
+	defer memory.Unlock()
+
+	device, exists := memory.devices[deviceID]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+	d := *device
+	return &d, nil
+}
+
+// デバイスを取得 (未登録の場合は作成、memory.Lock を持って呼ぶ)
+func memoryDevice(deviceID string) *models.Device {
+	device, exists := memory.devices[deviceID]
+	if !exists {
+		device = &models.Device{ID: deviceID, Group: "default", CreatedAt: time.Now()}
+		memory.devices[deviceID] = device
+	}
+	return device
+}
+
+func memorySaveDevice(d *models.Device) {
+	memory.Lock()
+	defer memory.Unlock()
+
+	device := memoryDevice(d.ID)
+	device.Name = d.Name
+	device.OwnerID = d.OwnerID
+	device.Type = d.Type
+	device.Group = d.Group
+}
+
+func memoryDeleteDevice(deviceID string) {
+	memory.Lock()
+	defer memory.Unlock()
+	delete(memory.devices, deviceID)
+}
+
+// ペアリングしているデバイスを取得
+func memoryGetDeviceBindings() []models.Device {
+	var bindings []models.Device
+	for _, device := range memoryGetDevices() {
+		if device.PairedPlayer != "" {
+			bindings = append(bindings, device)
+		}
+	}
+	return bindings
+}
+
+// デバイスのペアリングを解除 (未登録の場合は何もしない)
+func memoryClearDeviceBinding(deviceID string) {
+	memory.Lock()
+	defer memory.Unlock()
+
+	if device, exists := memory.devices[deviceID]; exists {
+		device.PairedPlayer = ""
+		device.PairedAt = nil
+	}
+}
+
+func memoryUpsertDeviceColumns(deviceID string, values map[string]interface{}) {
+	memory.Lock()
+	defer memory.Unlock()
+
+	device := memoryDevice(deviceID)
+	for column, value := range values {
+		setDeviceColumn(device, column, value)
+	}
+}
+
+// UpsertDeviceColumns で更新するカラムをデバイスに反映する
+func setDeviceColumn(device *models.Device, column string, value interface{}) {
+	switch column {
+	case "last_seen_at":
+		device.LastSeenAt = timeValue(value)
+	case "last_update_at":
+		device.LastUpdateAt = timeValue(value)
+	case "battery_level":
+		device.BatteryLevel = intValue(value)
+	case "rssi":
+		device.RSSI = intValue(value)
+	case "error_count":
+		if n := intValue(value); n != nil {
+			device.ErrorCount = *n
+		}
+	case "input_profile_id":
+		device.InputProfileID = intValue(value)
+	case "paired_player":
+		device.PairedPlayer, _ = value.(string)
+	case "paired_at":
+		device.PairedAt = timeValue(value)
+	case "secret":
+		device.Secret, _ = value.(string)
+	case "firmware_version":
+		device.FirmwareVersion, _ = value.(string)
+	case "last_update_version":
+		device.LastUpdateVersion, _ = value.(string)
+	case "last_update_status":
+		device.LastUpdateStatus, _ = value.(string)
+	case "last_update_error":
+		device.LastUpdateError, _ = value.(string)
+	}
+}
+
+func timeValue(value interface{}) *time.Time {
+	switch v := value.(type) {
+	case time.Time:
+		return &v
+	case *time.Time:
+		return v
+	}
+	return nil
+}
+
+func intValue(value interface{}) *int {
+	switch v := value.(type) {
+	case int:
+		return &v
+	case *int:
+		return v
+	}
+	return nil
+}
+
+func memoryGetDeviceSecrets() map[string]string {
+	memory.Lock()
+	defer memory.Unlock()
+
+	secrets := map[string]string{}
+	for id, device := range memory.devices {
+		if device.Secret != "" {
+			secrets[id] = device.Secret
+		}
+	}
+	return secrets
+}
+
+func memoryGetInputProfiles() []models.InputProfile {
+	memory.Lock()
+	defer memory.Unlock()
+
+	profiles := make([]models.InputProfile, 0, len(memory.profiles))
+	for _, profile := range memory.profiles {
+		profiles = append(profiles, *profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].DeviceType != profiles[j].DeviceType {
+			return profiles[i].DeviceType < profiles[j].DeviceType
+		}
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
+
+func memoryGetInputProfile(match func(*models.InputProfile) bool) (*models.InputProfile, error) {
+	memory.Lock()
+	defer memory.Unlock()
+
+	for _, profile := range memory.profiles {
+		if match(profile) {
+			p := *profile
+			return &p, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func memorySaveInputProfile(profile *models.InputProfile) error {
+	memory.Lock()
+	defer memory.Unlock()
+
+	now := time.Now()
+	if profile.ID == 0 {
+		profile.ID = memory.nextProfileID
+		memory.nextProfileID++
+		profile.CreatedAt = now
+	} else if existing, exists := memory.profiles[profile.ID]; exists {
+		profile.CreatedAt = existing.CreatedAt
+	} else {
+		return gorm.ErrRecordNotFound
+	}
+	profile.UpdatedAt = now
+
+	if profile.IsDefault {
+		for _, other := range memory.profiles {
+			if other.DeviceType == profile.DeviceType && other.ID != profile.ID {
+				other.IsDefault = false
+			}
+		}
+	}
+	p := *profile
+	memory.profiles[profile.ID] = &p
+	return nil
+}
+
+func memoryDeleteInputProfile(profileID int) bool {
+	memory.Lock()
+	defer memory.Unlock()
+
+	if _, exists := memory.profiles[profileID]; !exists {
+		return false
+	}
+	delete(memory.profiles, profileID)
+	// 割り当てられていたデバイスは解除する (ON DELETE SET NULL と同じ)
+	for _, device := range memory.devices {
+		if device.InputProfileID != nil && *device.InputProfileID == profileID {
+			device.InputProfileID = nil
+		}
+	}
+	return true
+}
+
+func memoryGetDeviceInputMappings() map[string]models.InputMappings {
+	memory.Lock()
+	defer memory.Unlock()
+
+	mappings := map[string]models.InputMappings{}
+	for id, device := range memory.devices {
+		if device.InputProfileID == nil {
+			continue
+		}
+		if profile, exists := memory.profiles[*device.InputProfileID]; exists {
+			mappings[id] = profile.Mappings
+		}
+	}
+	return mappings
+}
@@ -5,6 +5,11 @@ import (
 )
 
 func GetUsers() ([]models.User, error) {
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
 	var users []models.User
 
 	query := db.Table("users")
@@ -18,6 +23,11 @@ func GetUsers() ([]models.User, error) {
 }
 
 func GetUser(userId string) (*models.User, error) {
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
 	var user models.User
 
 	query := db.Table("users").Where("id = ?", userId)
@@ -31,6 +41,11 @@ func GetUser(userId string) (*models.User, error) {
 }
 
 func GetUserByName(name string) (*models.User, error) {
+	db, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
 	var user models.User
 
 	query := db.Table("users").Where("name = ?", name)
@@ -44,6 +59,11 @@ func GetUserByName(name string) (*models.User, error) {
 }
 
 func CreateUser(newUser *models.User) error {
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
 	result := db.Create(newUser)
 	if result.Error != nil {
 		return result.Error
@@ -52,9 +72,14 @@ func CreateUser(newUser *models.User) error {
 }
 
 func UpdateUser(user *models.User) error {
+	db, err := conn()
+	if err != nil {
+		return err
+	}
+
 	result := db.Save(user)
 	if result.Error != nil {
 		return result.Error
 	}
 	return nil
-}
\ No newline at end of file
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"fmt"
 	"md2s/controllers"
+	"md2s/services"
 	"os"
 	"time"
 
@@ -38,16 +39,110 @@ func Init() {
 	// websocketのエンドポイント
 	r.GET("/ws", controllers.HandleWebSocket)
 
-
 	// デバイスの入力を処理するエンドポイント
 	r.POST("/device/input", controllers.ProcessDeviceInputHandler)
 
+	// デバイスへのコマンド (WebSocketを使わないデバイス用)
+	r.GET("/device/commands", controllers.PollDeviceCommandsHandler)
+
+	// デバイスの時刻同期 (WebSocketを使わないデバイス用)
+	r.POST("/device/clock", controllers.ClockSyncHandler)
+	r.POST("/device/clock/result", controllers.ClockSyncResultHandler)
+
+	admin := r.Group("/", controllers.RequireAdmin)
+
+	// デバイスのペアリング (コードの発行はプレイヤー自身のユーザーか管理者、ペアリングは署名したデバイスか管理者、紐付けの解除は管理者のみ)
+	r.POST("/pairing/codes", controllers.CreatePairingCodeHandler)
+	r.POST("/device/pair", controllers.PairDeviceHandler)
+	r.GET("/devices/bindings", controllers.ListDeviceBindingsHandler)
+	admin.DELETE("/devices/bindings/:deviceId", controllers.RevokeDeviceBindingHandler)
+
+	// デバイスの認証情報 (管理者のみ)
+	admin.POST("/devices/:deviceId/credentials", controllers.IssueDeviceCredentialsHandler)
+	admin.DELETE("/devices/:deviceId/credentials", controllers.RevokeDeviceCredentialsHandler)
+	admin.GET("/devices/auth/stats", controllers.GetDeviceAuthStatsHandler)
+
+	// デバイスの登録情報 (管理者のみ)
+	admin.GET("/devices", controllers.ListDevicesHandler)
+	admin.PUT("/devices/:deviceId", controllers.SaveDeviceHandler)
+	admin.DELETE("/devices/:deviceId", controllers.DeleteDeviceHandler)
+
+	// 入力プロファイル (管理者のみ)
+	admin.GET("/input-profiles", controllers.ListInputProfilesHandler)
+	admin.POST("/input-profiles", controllers.CreateInputProfileHandler)
+	admin.PUT("/input-profiles/:profileId", controllers.UpdateInputProfileHandler)
+	admin.DELETE("/input-profiles/:profileId", controllers.DeleteInputProfileHandler)
+	admin.PUT("/devices/:deviceId/input-profile", controllers.AssignInputProfileHandler)
+
+	// デバイスのテレメトリ
+	r.POST("/device/telemetry", controllers.DeviceTelemetryHandler)
+
+	// ファームウェアの配信 (登録と配信割合の設定は管理者のみ)
+	admin.POST("/firmware", controllers.UploadFirmwareHandler)
+	admin.GET("/firmware", controllers.ListFirmwaresHandler)
+	admin.DELETE("/firmware/:firmwareId", controllers.DeleteFirmwareHandler)
+	admin.GET("/firmware/:firmwareId/rollouts", controllers.ListFirmwareRolloutsHandler)
+	admin.PUT("/firmware/:firmwareId/rollouts", controllers.SetFirmwareRolloutHandler)
+	r.GET("/firmware/:firmwareId/download", controllers.DownloadFirmwareHandler)
+	r.GET("/device/firmware", controllers.CheckFirmwareUpdateHandler)
+	r.POST("/device/firmware/report", controllers.ReportFirmwareUpdateHandler)
+
+	// ジェスチャーのサンプルの記録 (管理者のみ)
+	admin.GET("/gestures/recordings", controllers.ListGestureRecordingsHandler)
+	admin.POST("/gestures/recordings", controllers.StartGestureRecordingHandler)
+	admin.DELETE("/gestures/recordings/:deviceId", controllers.StopGestureRecordingHandler)
+	admin.GET("/gestures/samples", controllers.ListGestureSamplesHandler)
+	admin.GET("/gestures/samples/export", controllers.ExportGestureSamplesHandler)
+	admin.PUT("/gestures/samples/:sampleId", controllers.LabelGestureSampleHandler)
+	admin.DELETE("/gestures/samples/:sampleId", controllers.DeleteGestureSampleHandler)
+
+	// 不正の疑いのあるデバイスの確認と停止 (審判・管理者のみ)
+	admin.GET("/referee/devices", controllers.ListFlaggedDevicesHandler)
+	admin.POST("/referee/devices/:deviceId/suspend", controllers.SuspendDeviceHandler)
+	admin.DELETE("/referee/devices/:deviceId/suspend", controllers.ClearDeviceFlagsHandler)
+
+	// ユーザーのトークンの発行 (管理者のみ)
+	admin.POST("/users/:userId/token", controllers.IssueUserTokenHandler)
+
+	// キャラクタークラスとユーザーのロードアウト (保存はそのユーザーのトークンか管理者のみ)
+	r.GET("/classes", controllers.ListClassesHandler)
+	r.GET("/users/:userId/loadout", controllers.GetLoadoutHandler)
+	r.PUT("/users/:userId/loadout", controllers.RequireUser, controllers.SaveLoadoutHandler)
+
+	// 試合前のロビー (準備確認の開始・準備完了・取り消し・クラスの確定は呼び出し元のプレイヤーとして行う、lobby.go を参照)
+	r.GET("/lobby", controllers.GetLobbyHandler)
+	r.POST("/lobby/ready-check", controllers.StartReadyCheckHandler)
+	r.POST("/lobby/ready", controllers.ConfirmReadyHandler)
+	r.POST("/lobby/cancel", controllers.CancelReadyCheckHandler)
+	r.POST("/lobby/pick", controllers.PickClassHandler)
+
+	// アリーナの特殊ルール
+	r.GET("/arena", controllers.GetArenaHandler)
+	admin.PUT("/arena/next", controllers.SetNextArenaHandler)
+
+	// 試合中のパワーアップ
+	r.GET("/powerups", controllers.GetPowerUpsHandler)
+	admin.PUT("/powerups/seed", controllers.SetNextPowerUpSeedHandler)
+
 	// 現在のゲーム状態を取得するエンドポイント
 	r.GET("/game/state", controllers.GetGameStateHandler)
 
 	// プレイヤーのWebSocket接続を処理するエンドポイント
 	r.GET("/player/ws", controllers.HandlePlayerWebSocket)
 
+	// 保存されたデバイスの紐付けと入力プロファイルの対応表を読み込む
+	services.LoadDeviceBindings()
+	services.LoadInputMappings()
+
+	// MQTTブリッジを開始 (MQTT_MODE が設定されている場合のみ)
+	controllers.StartMQTTBridge()
+
+	// UDPリスナーを開始 (UDP_ADDR が設定されている場合のみ)
+	controllers.StartUDPListener()
+
+	// gRPCサーバーを開始 (GRPC_ADDR が設定されている場合のみ)
+	controllers.StartGRPCServer()
+
 	// 指定されたポートでサーバーを開始
 	if err := r.Run(fmt.Sprintf(":%s", port)); err != nil {
 		fmt.Printf("Failed to start server: %s\n", err)
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 保持する検知記録の数
+const cheatFlagHistory = 20
+
+// この時間使われなかったトークンバケットは破棄する
+const bucketIdleTimeout = time.Minute
+
+var (
+	ErrRateLimited     = errors.New("rate limited")
+	ErrDeviceSuspended = errors.New("device suspended")
+)
+
+// デバイスの不正検知の状態
+const (
+	CheatStatusOK        = "ok"
+	CheatStatusThrottled = "throttled"
+	CheatStatusSuspended = "suspended"
+)
+
+// 不正検知の理由
+const (
+	CheatReasonInhumanReaction  = "inhuman_reaction"
+	CheatReasonRegularIntervals = "regular_intervals"
+	CheatReasonActionRate       = "action_rate"
+	CheatReasonReferee          = "referee"
+)
+
+// CheatFlag 不正の疑いの検知
+type CheatFlag struct {
+	Reason string    `json:"reason"`
+	Detail string    `json:"detail"`
+	At     time.Time `json:"at"`
+}
+
+// DeviceCheatStatus デバイスの不正検知の状態 (審判向け)
+type DeviceCheatStatus struct {
+	DeviceID      string         `json:"deviceId"`
+	Status        string         `json:"status"`
+	Flags         int            `json:"flags"`
+	ByReason      map[string]int `json:"byReason"`
+	Recent        []CheatFlag    `json:"recent"`
+	RateLimited   int            `json:"rateLimited"` // レート制限で破棄した入力の数
+	LastFlaggedAt time.Time      `json:"lastFlaggedAt"`
+}
+
+// トークンバケット
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) allow(now time.Time, rate, burst float64) bool {
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+var (
+	deviceBuckets   = map[string]*tokenBucket{}
+	ipBuckets       = map[string]*tokenBucket{}
+	bucketsPrunedAt time.Time
+	cheatStatuses   = map[string]*DeviceCheatStatus{}
+	deviceActions   = map[string][]time.Time{} // デバイスID → 直近の行動時刻
+	antiCheatMu     sync.Mutex
+)
+
+// AllowDeviceInput デバイスとIPアドレスごとのレート制限 (ip が空の場合はデバイスのみ)
+// 不正の疑いで制限中のデバイスはレートを下げ、停止中のデバイスは拒否する
+// ペアリングされていないデバイスは状態を作らずに拒否する (ErrInvalidDevice、mu を持たずに呼ぶ)
+func AllowDeviceInput(deviceID, ip string) error {
+	if !DevicePaired(deviceID) {
+		return ErrInvalidDevice
+	}
+
+	antiCheatMu.Lock()
+	defer antiCheatMu.Unlock()
+
+	now := time.Now()
+	config := rules.AntiCheat
+	pruneBuckets(now)
+
+	status := cheatStatuses[deviceID]
+	rate, burst := config.DeviceRate, config.DeviceBurst
+	if status != nil {
+		switch status.Status {
+		case CheatStatusSuspended:
+			return ErrDeviceSuspended
+		case CheatStatusThrottled:
+			rate *= config.ThrottleFactor
+			burst = math.Max(1, burst*config.ThrottleFactor)
+		}
+	}
+
+	allowed := takeToken(deviceBuckets, deviceID, now, rate, burst)
+	if allowed && ip != "" {
+		allowed = takeToken(ipBuckets, ip, now, config.IPRate, config.IPBurst)
+	}
+	if !allowed {
+		if status == nil {
+			status = newCheatStatus(deviceID)
+		}
+		status.RateLimited++
+		return ErrRateLimited
+	}
+	return nil
+}
+
+func takeToken(buckets map[string]*tokenBucket, key string, now time.Time, rate, burst float64) bool {
+	b, exists := buckets[key]
+	if !exists {
+		b = &tokenBucket{tokens: burst, last: now}
+		buckets[key] = b
+	}
+	return b.allow(now, rate, burst)
+}
+
+// 使われていないトークンバケットと、古い行動の記録を破棄 (満タンに戻っているので作り直しても同じ)
+func pruneBuckets(now time.Time) {
+	if now.Sub(bucketsPrunedAt) < bucketIdleTimeout {
+		return
+	}
+	bucketsPrunedAt = now
+	for _, buckets := range []map[string]*tokenBucket{deviceBuckets, ipBuckets} {
+		for key, b := range buckets {
+			if now.Sub(b.last) > bucketIdleTimeout {
+				delete(buckets, key)
+			}
+		}
+	}
+	for deviceID, history := range deviceActions {
+		if len(history) == 0 || now.Sub(history[len(history)-1]) > bucketIdleTimeout {
+			delete(deviceActions, deviceID)
+		}
+	}
+}
+
+// 停止中のデバイスか
+func deviceSuspended(deviceID string) bool {
+	antiCheatMu.Lock()
+	defer antiCheatMu.Unlock()
+	status, exists := cheatStatuses[deviceID]
+	return exists && status.Status == CheatStatusSuspended
+}
+
+// 戦闘中の行動を調べて人間離れした入力を検知する (at は補正後の行動時刻)
+func inspectDeviceAction(deviceID string, player, opponent *Player, action string, at time.Time) {
+	config := rules.AntiCheat
+
+	// 相手の攻撃に対して早すぎる防御
+	// at は遅延を補正した時刻なので、攻撃の後であれば反応時間そのものとして MinReactionMs と比べる
+	// 攻撃と同時か前の防御は反応ではない (遅い防御としてルールで扱う)
+	if action == "defend" && opponent.Action == "attack" {
+		reaction := at.Sub(opponent.ActionAt)
+		if reaction > 0 && reaction < time.Duration(config.MinReactionMs)*time.Millisecond {
+			flagDevice(deviceID, player, CheatReasonInhumanReaction, fmt.Sprintf("defended %dms after attack", reaction.Milliseconds()))
+		}
+	}
+
+	antiCheatMu.Lock()
+	history := append(deviceActions[deviceID], at)
+	if keep := max(config.RegularWindow+1, config.MaxActionsPerSecond+1); len(history) > keep {
+		history = history[len(history)-keep:]
+	}
+	deviceActions[deviceID] = history
+	antiCheatMu.Unlock()
+
+	// 1秒あたりの行動数
+	count := 0
+	for _, t := range history {
+		if at.Sub(t) < time.Second {
+			count++
+		}
+	}
+	if count > config.MaxActionsPerSecond {
+		flagDevice(deviceID, player, CheatReasonActionRate, fmt.Sprintf("%d actions in 1s", count))
+		return
+	}
+
+	// 機械的に一定な行動間隔
+	if config.RegularWindow < 2 || len(history) < config.RegularWindow+1 {
+		return
+	}
+	intervals := make([]float64, 0, config.RegularWindow)
+	for i := len(history) - config.RegularWindow; i < len(history); i++ {
+		intervals = append(intervals, float64(history[i].Sub(history[i-1]).Milliseconds()))
+	}
+	if mean, stddev := meanStddev(intervals); stddev < config.RegularJitterMs {
+		flagDevice(deviceID, player, CheatReasonRegularIntervals, fmt.Sprintf("interval %.0fms ± %.1fms", mean, stddev))
+	}
+}
+
+func meanStddev(values []float64) (mean, stddev float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		stddev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(values)))
+}
+
+// 不正の疑いを記録し、回数に応じて制限・停止する
+// 同じ行動で繰り返し検知しないよう、行動の記録はリセットする
+func flagDevice(deviceID string, player *Player, reason, detail string) {
+	antiCheatMu.Lock()
+	defer antiCheatMu.Unlock()
+
+	status, exists := cheatStatuses[deviceID]
+	if !exists {
+		status = newCheatStatus(deviceID)
+	}
+	now := time.Now()
+	status.Flags++
+	status.ByReason[reason]++
+	status.LastFlaggedAt = now
+	status.Recent = append(status.Recent, CheatFlag{Reason: reason, Detail: detail, At: now})
+	if len(status.Recent) > cheatFlagHistory {
+		status.Recent = status.Recent[len(status.Recent)-cheatFlagHistory:]
+	}
+	delete(deviceActions, deviceID)
+
+	config := rules.AntiCheat
+	switch {
+	case status.Flags >= config.SuspendAfter:
+		status.Status = CheatStatusSuspended
+	case status.Flags >= config.ThrottleAfter && status.Status == CheatStatusOK:
+		status.Status = CheatStatusThrottled
+	}
+
+	log.Printf("Device %s flagged for %s (%s), status %s", deviceID, reason, detail, status.Status)
+	emitGameEvent("device_flagged", player, nil, status.Flags)
+}
+
+func newCheatStatus(deviceID string) *DeviceCheatStatus {
+	status := &DeviceCheatStatus{DeviceID: deviceID, Status: CheatStatusOK, ByReason: map[string]int{}}
+	cheatStatuses[deviceID] = status
+	return status
+}
+
+// ロックの外で使えるようにコピーする
+func (s *DeviceCheatStatus) snapshot() DeviceCheatStatus {
+	copied := *s
+	copied.ByReason = make(map[string]int, len(s.ByReason))
+	for k, v := range s.ByReason {
+		copied.ByReason[k] = v
+	}
+	copied.Recent = append([]CheatFlag(nil), s.Recent...)
+	return copied
+}
+
+// ListDeviceCheatStatuses 不正の疑いやレート制限のあったデバイスの一覧を取得
+func ListDeviceCheatStatuses() []DeviceCheatStatus {
+	antiCheatMu.Lock()
+	defer antiCheatMu.Unlock()
+
+	statuses := make([]DeviceCheatStatus, 0, len(cheatStatuses))
+	for _, s := range cheatStatuses {
+		statuses = append(statuses, s.snapshot())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Flags > statuses[j].Flags
+	})
+	return statuses
+}
+
+// SuspendDevice 審判がデバイスの入力を停止する
+func SuspendDevice(deviceID, detail string) DeviceCheatStatus {
+	antiCheatMu.Lock()
+	defer antiCheatMu.Unlock()
+
+	status, exists := cheatStatuses[deviceID]
+	if !exists {
+		status = newCheatStatus(deviceID)
+	}
+	status.Status = CheatStatusSuspended
+	status.Recent = append(status.Recent, CheatFlag{Reason: CheatReasonReferee, Detail: detail, At: time.Now()})
+	log.Printf("Device %s suspended by referee", deviceID)
+	return status.snapshot()
+}
+
+// ClearDeviceFlags 審判がデバイスの検知記録を消して制限を解除する
+func ClearDeviceFlags(deviceID string) {
+	antiCheatMu.Lock()
+	defer antiCheatMu.Unlock()
+	delete(cheatStatuses, deviceID)
+	delete(deviceActions, deviceID)
+	delete(deviceBuckets, deviceID)
+	log.Printf("Device %s flags cleared by referee", deviceID)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestInspectDeviceActionReaction(t *testing.T) {
+	attackAt := time.Now()
+	minReaction := time.Duration(rules.AntiCheat.MinReactionMs) * time.Millisecond
+
+	tests := []struct {
+		name           string
+		opponentAction string
+		defendAt       time.Time
+		flagged        bool
+	}{
+		{"defend before attack", "attack", attackAt.Add(-50 * time.Millisecond), false},
+		{"defend at attack", "attack", attackAt, false},
+		{"80ms reaction", "attack", attackAt.Add(80 * time.Millisecond), true},
+		{"inhuman reaction just under minimum", "attack", attackAt.Add(minReaction - time.Millisecond), true},
+		{"human reaction", "attack", attackAt.Add(minReaction), false},
+		{"opponent not attacking", "defend", attackAt.Add(80 * time.Millisecond), false},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceID := fmt.Sprintf("test-reaction-%d", i)
+			defer ClearDeviceFlags(deviceID)
+
+			player := &Player{ID: "player1"}
+			opponent := &Player{ID: "player2", Action: tt.opponentAction, ActionAt: attackAt}
+			inspectDeviceAction(deviceID, player, opponent, "defend", tt.defendAt)
+
+			flags := 0
+			antiCheatMu.Lock()
+			if status, exists := cheatStatuses[deviceID]; exists {
+				flags = status.ByReason[CheatReasonInhumanReaction]
+			}
+			antiCheatMu.Unlock()
+			if got := flags > 0; got != tt.flagged {
+				t.Errorf("flagged = %v, want %v", got, tt.flagged)
+			}
+		})
+	}
+}
+
+func TestFlagDeviceStatus(t *testing.T) {
+	config := rules.AntiCheat
+
+	tests := []struct {
+		flags int
+		want  string
+	}{
+		{config.ThrottleAfter - 1, CheatStatusOK},
+		{config.ThrottleAfter, CheatStatusThrottled},
+		{config.SuspendAfter - 1, CheatStatusThrottled},
+		{config.SuspendAfter, CheatStatusSuspended},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d flags", tt.flags), func(t *testing.T) {
+			deviceID := fmt.Sprintf("test-flags-%d", tt.flags)
+			defer ClearDeviceFlags(deviceID)
+
+			for i := 0; i < tt.flags; i++ {
+				flagDevice(deviceID, nil, CheatReasonActionRate, "test")
+			}
+
+			antiCheatMu.Lock()
+			status := CheatStatusOK
+			if s, exists := cheatStatuses[deviceID]; exists {
+				status = s.Status
+			}
+			antiCheatMu.Unlock()
+			if status != tt.want {
+				t.Errorf("status = %s, want %s", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowDeviceInputSuspended(t *testing.T) {
+	const deviceID = "test-suspended"
+	pairTestDevice(t, deviceID, "player1")
+	defer ClearDeviceFlags(deviceID)
+
+	if err := AllowDeviceInput(deviceID, ""); err != nil {
+		t.Fatalf("AllowDeviceInput() = %v, want nil", err)
+	}
+	SuspendDevice(deviceID, "test")
+	if err := AllowDeviceInput(deviceID, ""); err != ErrDeviceSuspended {
+		t.Errorf("AllowDeviceInput() = %v, want %v", err, ErrDeviceSuspended)
+	}
+}
+
+func TestAllowDeviceInputUnpaired(t *testing.T) {
+	const deviceID = "test-unpaired-input"
+
+	if err := AllowDeviceInput(deviceID, "192.0.2.1"); err != ErrInvalidDevice {
+		t.Fatalf("AllowDeviceInput() = %v, want %v", err, ErrInvalidDevice)
+	}
+
+	antiCheatMu.Lock()
+	_, bucket := deviceBuckets[deviceID]
+	_, status := cheatStatuses[deviceID]
+	antiCheatMu.Unlock()
+	if bucket || status {
+		t.Errorf("state was created for an unpaired device (bucket %v, status %v)", bucket, status)
+	}
+}
+
+func TestPruneBuckets(t *testing.T) {
+	now := time.Now()
+
+	antiCheatMu.Lock()
+	defer antiCheatMu.Unlock()
+
+	deviceBuckets["test-idle"] = &tokenBucket{last: now.Add(-2 * bucketIdleTimeout)}
+	deviceBuckets["test-active"] = &tokenBucket{last: now}
+	deviceActions["test-idle"] = []time.Time{now.Add(-2 * bucketIdleTimeout)}
+	deviceActions["test-active"] = []time.Time{now}
+	defer func() {
+		delete(deviceBuckets, "test-active")
+		delete(deviceActions, "test-active")
+	}()
+
+	bucketsPrunedAt = time.Time{}
+	pruneBuckets(now)
+
+	if _, exists := deviceBuckets["test-idle"]; exists {
+		t.Error("idle bucket was not pruned")
+	}
+	if _, exists := deviceActions["test-idle"]; exists {
+		t.Error("idle action history was not pruned")
+	}
+	if deviceBuckets["test-active"] == nil || deviceActions["test-active"] == nil {
+		t.Error("active state was pruned")
+	}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+// アリーナの効果の種類
+const (
+	arenaDamage     = "damage"      // 攻撃のダメージを Value 倍
+	arenaAttackCost = "attack_cost" // 攻撃で消費するMPを Value 倍
+	arenaDefendCost = "defend_cost" // 防御で消費するDFを Value 倍
+	arenaBlock      = "block"       // 防御で防ぐダメージの割合を Value 倍
+	arenaCollection = "collection"  // collection で回復するMPを Value 倍
+	arenaMPDrain    = "mp_drain"    // 毎秒 Value のMPが減る
+)
+
+// MPの減少を反映する間隔
+const arenaTickInterval = 200 * time.Millisecond
+
+var ErrUnknownArenaModifier = errors.New("unknown arena modifier")
+
+// ArenaEffect 特殊ルールの効果
+type ArenaEffect struct {
+	Kind  string  `json:"kind"`
+	Value float64 `json:"value"`
+	// 試合開始からこの時間 (ミリ秒) が経つと有効になる
+	ActiveAfterMs int64 `json:"activeAfterMs,omitempty"`
+}
+
+// ArenaModifier アリーナの特殊ルール
+type ArenaModifier struct {
+	Description string        `json:"description"`
+	Effects     []ArenaEffect `json:"effects"`
+}
+
+// NamedArenaModifier 一覧に表示する特殊ルール
+type NamedArenaModifier struct {
+	Name string `json:"name"`
+	ArenaModifier
+}
+
+// ArenaStatus 現在の試合と次の試合の特殊ルール
+type ArenaStatus struct {
+	Modifiers []string             `json:"modifiers"`
+	Next      []string             `json:"next,omitempty"` // 管理者が指定した次の試合の特殊ルール
+	Available []NamedArenaModifier `json:"available"`
+}
+
+// 既定の特殊ルール (ルール設定の arenaModifiers で上書き・追加できる)
+var defaultArenaModifiers = map[string]ArenaModifier{
+	"mana_leak": {
+		Description: "MPが時間とともに減っていく",
+		Effects:     []ArenaEffect{{Kind: arenaMPDrain, Value: 2}},
+	},
+	"sudden_death": {
+		Description: "試合開始から90秒後はダメージが2倍になる",
+		Effects:     []ArenaEffect{{Kind: arenaDamage, Value: 2, ActiveAfterMs: 90000}},
+	},
+	"iron_wall": {
+		Description: "防御でDFを消費しないが、防げるダメージは半分になる",
+		Effects:     []ArenaEffect{{Kind: arenaDefendCost, Value: 0}, {Kind: arenaBlock, Value: 0.5}},
+	},
+}
+
+// アリーナの状態 (mu で保護する)
+var arena = struct {
+	modifiers []string      // 現在の試合の特殊ルール
+	effects   []ArenaEffect // 現在の試合の効果
+	startedAt time.Time     // 試合開始時刻 (試合中でなければゼロ値)
+	next      []string      // 次の試合の特殊ルール (nil の場合はルール設定に従う)
+	drain     map[string]float64
+	drainedAt time.Time
+}{drain: map[string]float64{}}
+
+var arenaTicker = startArenaTicker()
+
+func startArenaTicker() *time.Ticker {
+	ticker := time.NewTicker(arenaTickInterval)
+	go func() {
+		for range ticker.C {
+			tickArena()
+		}
+	}()
+	return ticker
+}
+
+// 特殊ルールの設定を取得
+func arenaModifier(name string) (ArenaModifier, bool) {
+	if modifier, ok := rules.ArenaModifiers[name]; ok {
+		return modifier, true
+	}
+	modifier, ok := defaultArenaModifiers[name]
+	return modifier, ok
+}
+
+// GetArena 現在の試合の特殊ルールと選択できる特殊ルールの一覧を取得
+func GetArena() ArenaStatus {
+	mu.Lock()
+	defer mu.Unlock()
+
+	names := map[string]bool{}
+	for name := range defaultArenaModifiers {
+		names[name] = true
+	}
+	for name := range rules.ArenaModifiers {
+		names[name] = true
+	}
+
+	status := ArenaStatus{
+		Modifiers: append([]string{}, arena.modifiers...),
+		Next:      append([]string(nil), arena.next...),
+		Available: make([]NamedArenaModifier, 0, len(names)),
+	}
+	for name := range names {
+		modifier, _ := arenaModifier(name)
+		status.Available = append(status.Available, NamedArenaModifier{Name: name, ArenaModifier: modifier})
+	}
+	sort.Slice(status.Available, func(i, j int) bool {
+		return status.Available[i].Name < status.Available[j].Name
+	})
+	return status
+}
+
+// SetNextArena 次の試合の特殊ルールを指定 (空の場合は特殊ルールなし、nil の場合はルール設定に戻す)
+func SetNextArena(names []string) error {
+	for _, name := range names {
+		if _, ok := arenaModifier(name); !ok {
+			return ErrUnknownArenaModifier
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	arena.next = names
+	return nil
+}
+
+// 試合の特殊ルールを選んで告知する
+func chooseArena() {
+	names := arena.next
+	arena.next = nil
+	if names == nil {
+		names = rules.Arena.Modifiers
+		if len(names) == 0 && rules.Arena.RandomCount > 0 {
+			names = randomArenaModifiers(rules.Arena.RandomCount)
+		}
+	}
+
+	arena.modifiers = make([]string, 0, len(names))
+	arena.effects = nil
+	for _, name := range names {
+		modifier, ok := arenaModifier(name)
+		if !ok {
+			log.Printf("Unknown arena modifier: %s", name)
+			continue
+		}
+		arena.modifiers = append(arena.modifiers, name)
+		arena.effects = append(arena.effects, modifier.Effects...)
+	}
+
+	if len(arena.modifiers) > 0 {
+		log.Printf("Arena modifiers: %v", arena.modifiers)
+	}
+	emitGameEvent("arena", nil, nil, len(arena.modifiers))
+}
+
+// 特殊ルールを n 個ランダムに選ぶ
+func randomArenaModifiers(n int) []string {
+	names := []string{}
+	for name := range defaultArenaModifiers {
+		names = append(names, name)
+	}
+	for name := range rules.ArenaModifiers {
+		if _, ok := defaultArenaModifiers[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	rand.Shuffle(len(names), func(i, j int) {
+		names[i], names[j] = names[j], names[i]
+	})
+	return names[:min(n, len(names))]
+}
+
+// 試合開始 (この時刻から時間で有効になる効果を数える)
+func startArena() {
+	arena.startedAt = time.Now()
+	arena.drainedAt = arena.startedAt
+	arena.drain = map[string]float64{}
+}
+
+// 試合が終わったので特殊ルールを解除
+func clearArena() {
+	arena.modifiers = nil
+	arena.effects = nil
+	arena.startedAt = time.Time{}
+	arena.drain = map[string]float64{}
+}
+
+// 現在有効な kind の効果の倍率 (効果がなければ 1)
+func arenaFactor(kind string) float64 {
+	if arena.startedAt.IsZero() {
+		return 1
+	}
+	elapsed := time.Since(arena.startedAt).Milliseconds()
+	factor := 1.0
+	for _, effect := range arena.effects {
+		if effect.Kind == kind && elapsed >= effect.ActiveAfterMs {
+			factor *= effect.Value
+		}
+	}
+	return factor
+}
+
+// 特殊ルールを反映した行動のコスト
+func arenaCost(cost int, kind string) int {
+	return int(math.Round(float64(cost) * arenaFactor(kind)))
+}
+
+// 強さと特殊ルールを反映した、防御で防ぐダメージの割合
+func blockRatio(power float64) float64 {
+	return math.Min(1, rules.Power.Block.apply(power)*arenaFactor(arenaBlock))
+}
+
+// 時間とともにMPが減る効果を反映して配信する
+func tickArena() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if arena.startedAt.IsZero() || GameOver {
+		return
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(arena.startedAt).Milliseconds()
+	perSecond := 0.0
+	for _, effect := range arena.effects {
+		if effect.Kind == arenaMPDrain && elapsed >= effect.ActiveAfterMs {
+			perSecond += effect.Value
+		}
+	}
+	seconds := now.Sub(arena.drainedAt).Seconds()
+	arena.drainedAt = now
+	if perSecond == 0 {
+		return
+	}
+
+	changed := false
+	for _, slot := range lobbySlots {
+		player := players[slot]
+		if player == nil || player.State != "fighting" && player.State != "guardBroken" {
+			continue
+		}
+		arena.drain[slot] += seconds * perSecond
+		drained := int(arena.drain[slot])
+		arena.drain[slot] -= float64(drained)
+		if drained > 0 && player.MP > 0 {
+			player.MP = max(0, player.MP-drained)
+			changed = true
+		}
+	}
+
+	if changed {
+		updateGameState()
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// アリーナの状態を元に戻す (mu を持って呼ぶ)
+func saveTestArena(t *testing.T) {
+	t.Helper()
+	saved := arena
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		arena = saved
+	})
+}
+
+func TestArenaFactor(t *testing.T) {
+	tests := []struct {
+		name      string
+		modifiers []string
+		elapsed   time.Duration // 試合開始からの経過時間 (0 の場合は試合中でない)
+		kind      string
+		want      float64
+	}{
+		{"特殊ルールなし", nil, time.Second, arenaDamage, 1},
+		{"試合中でなければ効果なし", []string{"iron_wall"}, 0, arenaBlock, 1},
+		{"防御で防げる割合が半分", []string{"iron_wall"}, time.Second, arenaBlock, 0.5},
+		{"防御のコストがなくなる", []string{"iron_wall"}, time.Second, arenaDefendCost, 0},
+		{"時間で有効になる効果の前", []string{"sudden_death"}, 89 * time.Second, arenaDamage, 1},
+		{"時間で有効になる効果の後", []string{"sudden_death"}, 91 * time.Second, arenaDamage, 2},
+		{"別の種類の効果は影響しない", []string{"sudden_death", "iron_wall"}, 91 * time.Second, arenaDamage, 2},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	saveTestArena(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arena.next = tt.modifiers
+			if tt.modifiers == nil {
+				arena.next = []string{}
+			}
+			chooseArena()
+			if tt.elapsed > 0 {
+				startArena()
+				arena.startedAt = time.Now().Add(-tt.elapsed)
+			} else {
+				arena.startedAt = time.Time{}
+			}
+
+			if got := arenaFactor(tt.kind); got != tt.want {
+				t.Errorf("arenaFactor(%s) = %v, want %v", tt.kind, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArenaCost(t *testing.T) {
+	mu.Lock()
+	defer mu.Unlock()
+	saveTestArena(t)
+
+	arena.effects = []ArenaEffect{{Kind: arenaAttackCost, Value: 1.5}}
+	arena.startedAt = time.Now()
+
+	if got := arenaCost(15, arenaAttackCost); got != 23 {
+		t.Errorf("arenaCost(15, attack_cost) = %d, want 23", got)
+	}
+	if got := arenaCost(15, arenaDefendCost); got != 15 {
+		t.Errorf("arenaCost(15, defend_cost) = %d, want 15", got)
+	}
+}
+
+func TestSetNextArena(t *testing.T) {
+	mu.Lock()
+	saveTestArena(t)
+	mu.Unlock()
+
+	if err := SetNextArena([]string{"mana_leak", "no_such_rule"}); err != ErrUnknownArenaModifier {
+		t.Fatalf("SetNextArena(unknown) = %v, want %v", err, ErrUnknownArenaModifier)
+	}
+	if err := SetNextArena([]string{"mana_leak"}); err != nil {
+		t.Fatal(err)
+	}
+	if status := GetArena(); len(status.Next) != 1 || status.Next[0] != "mana_leak" {
+		t.Fatalf("next = %v, want [mana_leak]", status.Next)
+	}
+
+	// 次の試合で使うと指定は消える
+	mu.Lock()
+	chooseArena()
+	mu.Unlock()
+	status := GetArena()
+	if len(status.Modifiers) != 1 || status.Modifiers[0] != "mana_leak" || status.Next != nil {
+		t.Errorf("modifiers = %v, next = %v, want [mana_leak] and no next", status.Modifiers, status.Next)
+	}
+}
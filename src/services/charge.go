@@ -0,0 +1,136 @@
+package services
+
+import (
+	"log"
+	"math"
+	"time"
+)
+
+// 溜め中のMP消費と進み具合を更新する間隔
+const chargeTickInterval = 100 * time.Millisecond
+
+var chargeTicker = startChargeTicker()
+
+func startChargeTicker() *time.Ticker {
+	ticker := time.NewTicker(chargeTickInterval)
+	go func() {
+		for range ticker.C {
+			tickCharges()
+		}
+	}()
+	return ticker
+}
+
+func (p *Player) charging() bool {
+	return !p.chargeStartedAt.IsZero()
+}
+
+// 溜めを開始 (at は補正後の時刻)
+func startCharge(player *Player, at time.Time) {
+	if player.MP == 0 {
+		log.Printf("Player %s has no MP", player.ID)
+		emitGameEvent("no_mp", player, nil, 0)
+		return
+	}
+
+	player.chargeStartedAt = at
+	player.chargeDrainedAt = time.Now()
+	player.chargeDrain = 0
+	player.chargeBase = player.attackDamage()
+	player.Charge = 0
+	log.Printf("Player %s started charging", player.ID)
+	emitGameEvent("charge_start", player, nil, 0)
+}
+
+// 溜めを離して攻撃する
+func releaseCharge(attacker, target *Player, at time.Time) {
+	if !attacker.charging() {
+		log.Printf("Player %s released a charge without charging", attacker.ID)
+		return
+	}
+	drainChargeMP(attacker, time.Now())
+
+	config := rules.Charge
+	held := at.Sub(attacker.chargeStartedAt).Milliseconds()
+	held = max(0, min(held, config.MaxHoldMs))
+	multiplier := 1.0
+	if held >= config.MinHoldMs && config.MaxHoldMs > 0 {
+		multiplier += (config.MaxMultiplier - 1) * float64(held) / float64(config.MaxHoldMs)
+	}
+	base := int(math.Round(float64(attacker.chargeBase) * multiplier))
+	resetCharge(attacker)
+
+	log.Printf("Player %s released a charge after %dms (x%.2f)", attacker.ID, held, multiplier)
+	emitGameEvent("charge_release", attacker, target, int(held))
+
+	// MPは溜めている間に消費済み
+	resolveAttack(attacker, target, at, base, 0)
+}
+
+// 攻撃を受けて溜めが中断された
+func interruptCharge(player *Player) {
+	if !player.charging() {
+		return
+	}
+	resetCharge(player)
+	log.Printf("Player %s's charge was interrupted", player.ID)
+	emitGameEvent("charge_interrupted", player, nil, 0)
+}
+
+func resetCharge(player *Player) {
+	player.chargeStartedAt = time.Time{}
+	player.chargeDrain = 0
+	player.Charge = 0
+}
+
+// 溜めている時間に応じてMPを消費する
+func drainChargeMP(player *Player, now time.Time) {
+	player.chargeDrain += now.Sub(player.chargeDrainedAt).Seconds() * rules.Charge.MPDrainPerSecond
+	player.chargeDrainedAt = now
+
+	drained := int(player.chargeDrain)
+	player.chargeDrain -= float64(drained)
+	player.MP = max(0, player.MP-drained)
+}
+
+// 溜め中のプレイヤーのMP消費と進み具合を更新して配信する
+// MPを使い切ったプレイヤーはその時点で溜めを離して攻撃する
+func tickCharges() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if lobbyPhase() != LobbyFighting || GameOver {
+		return
+	}
+
+	now := time.Now()
+	changed := false
+	for _, player := range players {
+		if !player.charging() {
+			continue
+		}
+		drainChargeMP(player, now)
+		changed = true
+
+		if player.MP == 0 {
+			target := players[opponentSlots[player.ID]]
+			if player.State != "fighting" || target == nil {
+				interruptCharge(player)
+				continue
+			}
+			log.Printf("Player %s ran out of MP while charging", player.ID)
+			releaseCharge(player, target, now)
+			continue
+		}
+
+		// 進み具合はサーバーの時刻で概算する (溜め開始時刻は補正済みのデバイスの時刻)
+		if rules.Charge.MaxHoldMs > 0 {
+			held := now.Sub(player.chargeStartedAt).Milliseconds()
+			player.Charge = int(min(100, held*100/rules.Charge.MaxHoldMs))
+		}
+	}
+
+	if changed {
+		updateGameState()
+	}
+}
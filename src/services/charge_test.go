@@ -0,0 +1,93 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReleaseCharge(t *testing.T) {
+	at := time.Now()
+	base := newTestPlayer("attacker").attackDamage()
+	config := rules.Charge
+
+	tests := []struct {
+		name       string
+		held       time.Duration
+		multiplier float64
+	}{
+		{"released too early", time.Duration(config.MinHoldMs-1) * time.Millisecond, 1},
+		{"half charged", time.Duration(config.MaxHoldMs/2) * time.Millisecond, 1 + (config.MaxMultiplier-1)/2},
+		{"fully charged", time.Duration(config.MaxHoldMs) * time.Millisecond, config.MaxMultiplier},
+		{"held past full charge", time.Duration(config.MaxHoldMs*2) * time.Millisecond, config.MaxMultiplier},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attacker := newTestPlayer("attacker")
+			target := newTestPlayer("target")
+
+			startCharge(attacker, at)
+			releaseCharge(attacker, target, at.Add(tt.held))
+
+			want := target.class.HP - int(float64(base)*tt.multiplier+0.5)
+			if target.HP != want {
+				t.Errorf("target HP = %d, want %d", target.HP, want)
+			}
+			if attacker.charging() {
+				t.Errorf("attacker is still charging")
+			}
+		})
+	}
+}
+
+func TestStartChargeWithoutMP(t *testing.T) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	player := newTestPlayer("player1")
+	player.MP = 0
+	startCharge(player, time.Now())
+	if player.charging() {
+		t.Errorf("player started charging without MP")
+	}
+}
+
+func TestTickCharges(t *testing.T) {
+	tests := []struct {
+		name         string
+		phase        string
+		mp           int
+		wantCharging bool
+		wantHit      bool
+	}{
+		{"keeps charging while fighting", LobbyFighting, 100, true, false},
+		{"ignored outside fights", LobbyCountdown, 0, true, false},
+		{"released when MP runs out", LobbyFighting, 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player, opponent := setupTestMatch(t)
+
+			mu.Lock()
+			lobby.phase = tt.phase
+			startCharge(player, time.Now())
+			player.MP = tt.mp
+			mu.Unlock()
+
+			tickCharges()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if player.charging() != tt.wantCharging {
+				t.Errorf("charging = %v, want %v", player.charging(), tt.wantCharging)
+			}
+			if hit := opponent.HP < opponent.class.HP; hit != tt.wantHit {
+				t.Errorf("opponent hit = %v, want %v", hit, tt.wantHit)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"md2s/models"
+	"md2s/repositorys"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ロードアウトがないユーザーやユーザーなしで参加したプレイヤーのクラス
+const defaultClass = "balanced"
+
+var (
+	ErrUnknownClass = errors.New("unknown class")
+	ErrInvalidClass = errors.New("class needs positive hp, mp and df and non-negative costs")
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// ClassRules キャラクタークラスの基本ステータスと行動のコスト
+type ClassRules struct {
+	HP int `json:"hp"`
+	MP int `json:"mp"`
+	DF int `json:"df"`
+	// 攻撃のダメージ = MP × DamageRatio
+	DamageRatio      float64       `json:"damageRatio"`
+	AttackCost       int           `json:"attackCost"`       // 攻撃で消費するMP
+	DefendCost       int           `json:"defendCost"`       // 防御で消費するDF
+	CollectionAmount int           `json:"collectionAmount"` // collection で回復するMP
+	Signature        SignatureMove `json:"signature"`
+}
+
+// SignatureMove クラス固有の技 ("signature" アクション)
+type SignatureMove struct {
+	Name string `json:"name"`
+	// "blast": MP × Value のダメージの攻撃、"fortify": DFを Value 回復、"heal": HPを Value 回復
+	Kind  string  `json:"kind"`
+	Cost  int     `json:"cost"` // 消費するMP
+	Value float64 `json:"value"`
+}
+
+// Class クラスの一覧に表示するクラス
+type Class struct {
+	Name string `json:"name"`
+	ClassRules
+}
+
+// 既定のクラス (ルール設定の classes で上書き・追加できる)
+var defaultClasses = map[string]ClassRules{
+	"balanced": {
+		HP: 100, MP: 100, DF: 100,
+		DamageRatio: 0.2, AttackCost: 20, DefendCost: 10, CollectionAmount: 10,
+		Signature: SignatureMove{Name: "Second Wind", Kind: "heal", Cost: 40, Value: 15},
+	},
+	"tank": {
+		HP: 130, MP: 80, DF: 150,
+		DamageRatio: 0.15, AttackCost: 20, DefendCost: 5, CollectionAmount: 8,
+		Signature: SignatureMove{Name: "Fortify", Kind: "fortify", Cost: 30, Value: 50},
+	},
+	"mage": {
+		HP: 80, MP: 120, DF: 70,
+		DamageRatio: 0.25, AttackCost: 20, DefendCost: 15, CollectionAmount: 15,
+		Signature: SignatureMove{Name: "Arcane Blast", Kind: "blast", Cost: 60, Value: 0.5},
+	},
+}
+
+// ルール設定の classes を既定のクラスに重ねる (指定しなかった項目は既定のクラスの値のまま)
+func loadClassOverrides(data []byte) (map[string]ClassRules, error) {
+	var config struct {
+		Classes map[string]json.RawMessage `json:"classes"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	classes := make(map[string]ClassRules, len(config.Classes))
+	for name, raw := range config.Classes {
+		class := defaultClasses[name]
+		if err := json.Unmarshal(raw, &class); err != nil {
+			return nil, fmt.Errorf("class %s: %w", name, err)
+		}
+		if err := class.validate(); err != nil {
+			return nil, fmt.Errorf("class %s: %w", name, err)
+		}
+		classes[name] = class
+	}
+	return classes, nil
+}
+
+func (c ClassRules) validate() error {
+	if c.HP <= 0 || c.MP <= 0 || c.DF <= 0 {
+		return ErrInvalidClass
+	}
+	if c.DamageRatio < 0 || c.AttackCost < 0 || c.DefendCost < 0 || c.CollectionAmount < 0 || c.Signature.Cost < 0 {
+		return ErrInvalidClass
+	}
+	return nil
+}
+
+// クラスの設定を取得
+func classRules(name string) (ClassRules, bool) {
+	if class, ok := rules.Classes[name]; ok {
+		return class, true
+	}
+	class, ok := defaultClasses[name]
+	return class, ok
+}
+
+// ListClasses 選択できるクラスの一覧を取得
+func ListClasses() []Class {
+	names := map[string]bool{}
+	for name := range defaultClasses {
+		names[name] = true
+	}
+	for name := range rules.Classes {
+		names[name] = true
+	}
+
+	classes := make([]Class, 0, len(names))
+	for name := range names {
+		class, _ := classRules(name)
+		classes = append(classes, Class{Name: name, ClassRules: class})
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		return classes[i].Name < classes[j].Name
+	})
+	return classes
+}
+
+// GetLoadout ユーザーのロードアウトを取得 (保存されていない場合は既定のクラス)
+func GetLoadout(userID string) (*models.Loadout, error) {
+	id, err := models.StringToUUID(userID)
+	if err != nil {
+		return nil, ErrInvalidUser
+	}
+
+	loadout, err := repositorys.GetLoadout(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Loadout{UserID: id, Class: defaultClass}, nil
+	}
+	return loadout, err
+}
+
+// SaveLoadout ユーザーのロードアウトを保存
+func SaveLoadout(userID, class string) (*models.Loadout, error) {
+	id, err := models.StringToUUID(userID)
+	if err != nil {
+		return nil, ErrInvalidUser
+	}
+	if _, ok := classRules(class); !ok {
+		return nil, ErrUnknownClass
+	}
+	if _, err := repositorys.GetUser(userID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	loadout := &models.Loadout{UserID: id, Class: class, UpdatedAt: time.Now()}
+	if err := repositorys.SaveLoadout(loadout); err != nil {
+		return nil, err
+	}
+	return loadout, nil
+}
+
+// ユーザーのロードアウトのクラス (ユーザーなし・データベースなし・読み込めない場合は既定のクラス)
+func loadoutClass(userID string) string {
+	if userID == "" || !repositorys.Enabled() {
+		return defaultClass
+	}
+	loadout, err := GetLoadout(userID)
+	if err != nil {
+		log.Printf("Failed to load loadout of user %s: %v", userID, err)
+		return defaultClass
+	}
+	if _, ok := classRules(loadout.Class); !ok {
+		return defaultClass
+	}
+	return loadout.Class
+}
+
+// プレイヤーにクラスを適用してステータスを初期化
+func applyClass(player *Player, name string) {
+	class, ok := classRules(name)
+	if !ok {
+		name = defaultClass
+		class, _ = classRules(name)
+	}
+	player.Class = name
+	player.class = class
+	player.HP = class.HP
+	player.MP = class.MP
+	player.DF = class.DF
+}
+
+// MPによる攻撃のダメージ (浮動小数点の誤差で1少なくならないように丸める)
+func (p *Player) attackDamage() int {
+	return int(float64(p.MP)*p.class.DamageRatio + 1e-9)
+}
+
+// クラス固有の技
+func processSignature(player, target *Player, at time.Time) {
+	move := player.class.Signature
+	if move.Kind == "" {
+		log.Printf("Player %s has no signature move", player.ID)
+		return
+	}
+	if player.MP < move.Cost {
+		log.Printf("Player %s has not enough MP for %s", player.ID, move.Name)
+		emitGameEvent("no_mp", player, target, 0)
+		return
+	}
+
+	switch move.Kind {
+	case "blast":
+		// 通常の攻撃と同じく、防御・パリィ・無敵時間で当たらなかった場合はMPを消費しない
+		base := int(math.Round(float64(player.MP) * move.Value))
+		emitGameEvent("signature", player, target, base)
+		resolveAttack(player, target, at, base, move.Cost)
+		return
+	case "fortify":
+		player.MP -= move.Cost
+		player.DF = min(player.class.DF, player.DF+int(move.Value))
+	case "heal":
+		player.MP -= move.Cost
+		player.HP = min(player.class.HP, player.HP+int(move.Value))
+	default:
+		log.Printf("Unknown signature move kind: %s", move.Kind)
+		return
+	}
+	log.Printf("Player %s used %s", player.ID, move.Name)
+	emitGameEvent("signature", player, nil, int(move.Value))
+}
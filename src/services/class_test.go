@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadClassOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		class   string
+		want    ClassRules
+		wantErr error
+	}{
+		{
+			name:   "partial override keeps defaults",
+			config: `{"classes":{"tank":{"hp":200}}}`,
+			class:  "tank",
+			want: func() ClassRules {
+				c := defaultClasses["tank"]
+				c.HP = 200
+				return c
+			}(),
+		},
+		{
+			name:   "signature field override",
+			config: `{"classes":{"mage":{"signature":{"cost":40}}}}`,
+			class:  "mage",
+			want: func() ClassRules {
+				c := defaultClasses["mage"]
+				c.Signature.Cost = 40
+				return c
+			}(),
+		},
+		{
+			name:   "new class",
+			config: `{"classes":{"rogue":{"hp":90,"mp":110,"df":60,"damageRatio":0.3,"attackCost":15}}}`,
+			class:  "rogue",
+			want:   ClassRules{HP: 90, MP: 110, DF: 60, DamageRatio: 0.3, AttackCost: 15},
+		},
+		{
+			name:    "new class without stats",
+			config:  `{"classes":{"rogue":{"damageRatio":0.3}}}`,
+			wantErr: ErrInvalidClass,
+		},
+		{
+			name:    "zero df",
+			config:  `{"classes":{"tank":{"df":0}}}`,
+			wantErr: ErrInvalidClass,
+		},
+		{
+			name:    "negative cost",
+			config:  `{"classes":{"balanced":{"attackCost":-1}}}`,
+			wantErr: ErrInvalidClass,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classes, err := loadClassOverrides([]byte(tt.config))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("loadClassOverrides() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadClassOverrides() error = %v", err)
+			}
+			if got := classes[tt.class]; got != tt.want {
+				t.Errorf("class %s = %+v, want %+v", tt.class, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessSignatureBlastCost(t *testing.T) {
+	at := time.Now()
+
+	tests := []struct {
+		name       string
+		setup      func(target *Player)
+		wantMP     int // 攻撃したプレイヤーの残りのMP
+		wantDamage bool
+	}{
+		{"当たればMPを消費する", func(*Player) {}, 60, true},
+		{"防御されたらMPを消費しない", func(target *Player) {
+			target.Action, target.ActionAt = "defend", at.Add(-time.Second)
+		}, 120, false},
+		{"パリィされたらMPを消費しない", func(target *Player) {
+			target.Action, target.ActionAt = "defend", at
+		}, 120, false},
+		{"無敵時間中はMPを消費しない", func(target *Player) {
+			target.invulnerableUntil = time.Now().Add(time.Minute)
+		}, 120, false},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attacker := newTestPlayer("attacker")
+			applyClass(attacker, "mage")
+			target := newTestPlayer("target")
+			tt.setup(target)
+
+			processSignature(attacker, target, at)
+
+			if attacker.MP != tt.wantMP {
+				t.Errorf("attacker MP = %d, want %d", attacker.MP, tt.wantMP)
+			}
+			if damaged := target.HP < target.class.HP; damaged != tt.wantDamage {
+				t.Errorf("target HP = %d, damaged = %v, want %v", target.HP, damaged, tt.wantDamage)
+			}
+		})
+	}
+}
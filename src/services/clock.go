@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"md2s/dto"
+	"sync"
+	"time"
+)
+
+// 保持する時刻同期のサンプル数 (RTTが最小のものを採用する)
+const clockSampleSize = 8
+
+var ErrInvalidClockSample = errors.New("invalid clock sync sample")
+
+// 時刻同期のサンプル
+type clockSample struct {
+	offset time.Duration // サーバー時刻 - デバイス時刻
+	rtt    time.Duration
+}
+
+// デバイスごとの時刻同期の状態
+type deviceClock struct {
+	samples []clockSample
+	offset  time.Duration
+	rtt     time.Duration
+}
+
+var (
+	deviceClocks   = map[string]*deviceClock{}
+	deviceClocksMu sync.Mutex
+)
+
+// ClockSyncReply 時刻同期リクエストへの応答
+type ClockSyncReply struct {
+	Type string `json:"type,omitempty"`
+	T0   int64  `json:"t0"`
+	T1   int64  `json:"t1"`
+	T2   int64  `json:"t2"`
+}
+
+// ClockSync 時刻同期リクエストに応答する (receivedAt はリクエストを受信した時刻)
+func ClockSync(t0 int64, receivedAt time.Time) ClockSyncReply {
+	return ClockSyncReply{
+		T0: t0,
+		T1: receivedAt.UnixMilli(),
+		T2: time.Now().UnixMilli(),
+	}
+}
+
+// RecordClockSync デバイスから報告された時刻同期の結果を記録し、推定したオフセットとRTTを返す
+// ペアリングされていないデバイスは記録しない (ErrInvalidDevice を返す)
+func RecordClockSync(result dto.ClockSyncResult) (offset, rtt time.Duration, err error) {
+	if !DevicePaired(result.DeviceID) {
+		return 0, 0, ErrInvalidDevice
+	}
+
+	rttMs := (result.T3 - result.T0) - (result.T2 - result.T1)
+	if rttMs < 0 || result.T2 < result.T1 {
+		return 0, 0, ErrInvalidClockSample
+	}
+
+	// NTPと同様に往路と復路の遅延が等しいと仮定してオフセットを求める
+	offsetMs := ((result.T1 - result.T0) + (result.T2 - result.T3)) / 2
+	sample := clockSample{
+		offset: time.Duration(offsetMs) * time.Millisecond,
+		rtt:    time.Duration(rttMs) * time.Millisecond,
+	}
+
+	deviceClocksMu.Lock()
+	defer deviceClocksMu.Unlock()
+
+	c, exists := deviceClocks[result.DeviceID]
+	if !exists {
+		c = &deviceClock{}
+		deviceClocks[result.DeviceID] = c
+	}
+	c.samples = append(c.samples, sample)
+	if len(c.samples) > clockSampleSize {
+		c.samples = c.samples[1:]
+	}
+
+	// RTTが最小のサンプルが最も正確
+	best := c.samples[0]
+	for _, s := range c.samples[1:] {
+		if s.rtt < best.rtt {
+			best = s
+		}
+	}
+	c.offset = best.offset
+	c.rtt = best.rtt
+
+	return c.offset, c.rtt, nil
+}
+
+// ペアリングが解除されたデバイスの時刻同期の結果を削除
+func forgetDeviceClock(deviceID string) {
+	deviceClocksMu.Lock()
+	defer deviceClocksMu.Unlock()
+	delete(deviceClocks, deviceID)
+}
+
+// デバイスのタイムスタンプをサーバー時刻に補正する
+// 同期していない場合やタイムスタンプがない場合は受信時刻を使う
+func correctedInputTime(deviceID string, timestamp int64, receivedAt time.Time) time.Time {
+	if timestamp == 0 {
+		return receivedAt
+	}
+
+	deviceClocksMu.Lock()
+	c, exists := deviceClocks[deviceID]
+	deviceClocksMu.Unlock()
+	if !exists {
+		return receivedAt
+	}
+
+	at := time.UnixMilli(timestamp).Add(c.offset)
+
+	// 未来の時刻や、遡りすぎた時刻は受け付けない
+	if at.After(receivedAt) {
+		return receivedAt
+	}
+	if earliest := receivedAt.Add(-rules.maxLagCompensation()); at.Before(earliest) {
+		return earliest
+	}
+	return at
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"md2s/dto"
+	"testing"
+	"time"
+)
+
+func TestRecordClockSync(t *testing.T) {
+	pairTestDevice(t, "clock-device", "player1")
+	t.Cleanup(func() { forgetDeviceClock("clock-device") })
+
+	tests := []struct {
+		name       string
+		result     dto.ClockSyncResult
+		wantErr    error
+		wantOffset time.Duration
+		wantRTT    time.Duration
+	}{
+		{
+			name:       "オフセットとRTTを求める",
+			result:     dto.ClockSyncResult{DeviceID: "clock-device", T0: 1000, T1: 1510, T2: 1520, T3: 1050},
+			wantOffset: 490 * time.Millisecond,
+			wantRTT:    40 * time.Millisecond,
+		},
+		{
+			name:       "RTTが大きいサンプルは採用しない",
+			result:     dto.ClockSyncResult{DeviceID: "clock-device", T0: 2000, T1: 2600, T2: 2610, T3: 2210},
+			wantOffset: 490 * time.Millisecond,
+			wantRTT:    40 * time.Millisecond,
+		},
+		{
+			name:       "RTTが小さいサンプルを採用する",
+			result:     dto.ClockSyncResult{DeviceID: "clock-device", T0: 3000, T1: 3505, T2: 3506, T3: 3011},
+			wantOffset: 500 * time.Millisecond,
+			wantRTT:    10 * time.Millisecond,
+		},
+		{
+			name:    "RTTが負のサンプルは拒否する",
+			result:  dto.ClockSyncResult{DeviceID: "clock-device", T0: 4000, T1: 4500, T2: 4600, T3: 4050},
+			wantErr: ErrInvalidClockSample,
+		},
+		{
+			name:    "ペアリングされていないデバイスは拒否する",
+			result:  dto.ClockSyncResult{DeviceID: "unknown-device", T0: 1000, T1: 1510, T2: 1520, T3: 1050},
+			wantErr: ErrInvalidDevice,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, rtt, err := RecordClockSync(tt.result)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if offset != tt.wantOffset || rtt != tt.wantRTT {
+				t.Errorf("offset, rtt = %v, %v, want %v, %v", offset, rtt, tt.wantOffset, tt.wantRTT)
+			}
+		})
+	}
+
+	deviceClocksMu.Lock()
+	_, exists := deviceClocks["unknown-device"]
+	deviceClocksMu.Unlock()
+	if exists {
+		t.Error("clock state was created for an unpaired device")
+	}
+}
+
+func TestCorrectedInputTime(t *testing.T) {
+	deviceClocksMu.Lock()
+	deviceClocks["corrected-device"] = &deviceClock{offset: 500 * time.Millisecond}
+	deviceClocksMu.Unlock()
+	t.Cleanup(func() { forgetDeviceClock("corrected-device") })
+
+	receivedAt := time.UnixMilli(10_000)
+	maxLag := rules.maxLagCompensation()
+
+	tests := []struct {
+		name      string
+		deviceID  string
+		timestamp int64
+		want      time.Time
+	}{
+		{"オフセットで補正する", "corrected-device", 9_450, time.UnixMilli(9_950)},
+		{"タイムスタンプがなければ受信時刻", "corrected-device", 0, receivedAt},
+		{"同期していなければ受信時刻", "unsynced-device", 9_450, receivedAt},
+		{"未来の時刻は受信時刻", "corrected-device", 9_600, receivedAt},
+		{"遡りすぎた時刻は補正の上限まで", "corrected-device", 1_000, receivedAt.Add(-maxLag)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := correctedInputTime(tt.deviceID, tt.timestamp, receivedAt); !got.Equal(tt.want) {
+				t.Errorf("correctedInputTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
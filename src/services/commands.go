@@ -0,0 +1,222 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"md2s/models"
+	"md2s/repositorys"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// HTTPのみのデバイスに溜めておくコマンドの数と有効期限
+	deviceCommandQueueSize = 32
+	deviceCommandTTL       = 10 * time.Second
+	// 送信待ちのイベントの数 (溢れた場合はコマンドを破棄する)
+	deviceCommandBuffer = 256
+)
+
+// DeviceCommandConfig イベントに対してデバイスが行う動作
+type DeviceCommandConfig struct {
+	Vibrate []int  `json:"vibrate,omitempty"` // 振動のパターン (オン・オフの時間をミリ秒で交互に)
+	LED     string `json:"led,omitempty"`     // LEDの色 ("#ff0000")
+	Sound   string `json:"sound,omitempty"`   // 効果音の名前
+}
+
+// DeviceCommand サーバーからデバイスに送るコマンド
+type DeviceCommand struct {
+	Type  string `json:"type"` // 常に "command"
+	Event string `json:"event"`
+	DeviceCommandConfig
+	Value     int   `json:"value"`
+	Timestamp int64 `json:"timestamp"`
+}
+
+// 既定のコマンド
+// イベントを起こしたプレイヤーのデバイスにはイベントの種類、対象のプレイヤーのデバイスには "<種類>:target" の設定を使う
+// プレイヤーのいないイベント (カウントダウンなど) は全てのデバイスに送る
+var defaultDeviceCommands = map[string]DeviceCommandConfig{
+	"hit":                 {LED: "#00ff00", Sound: "hit"},
+	"hit:target":          {Vibrate: []int{200}, LED: "#ff0000", Sound: "damage"},
+	"blocked":             {Vibrate: []int{50, 50, 50}, LED: "#ffff00", Sound: "blocked"},
+	"blocked:target":      {Vibrate: []int{80}, LED: "#0000ff", Sound: "guard"},
+	"parry":               {Vibrate: []int{40, 40, 40}, LED: "#00ffff", Sound: "parry"},
+	"parry:target":        {Vibrate: []int{250}, LED: "#ff00ff", Sound: "countered"},
+	"charge_start":        {LED: "#ff8800", Sound: "charge"},
+	"charge_release":      {Vibrate: []int{150}, Sound: "charge_release"},
+	"charge_interrupted":  {Vibrate: []int{100, 50, 100}, LED: "#ff0000", Sound: "interrupted"},
+	"guard_break":         {Vibrate: []int{600}, LED: "#ff0000", Sound: "guard_break"},
+	"guard_recover":       {Vibrate: []int{50, 50}, LED: "#ffffff", Sound: "recover"},
+	"signature":           {Vibrate: []int{100, 50, 100, 50, 300}, LED: "#aa00ff", Sound: "signature"},
+	"no_mp":               {Vibrate: []int{30, 30, 30}, Sound: "error"},
+	"ready_check_start":   {Vibrate: []int{100, 100, 100}, LED: "#ffff00", Sound: "ready_check"},
+	"ready_check_cancel":  {Vibrate: []int{200}, LED: "#ff0000", Sound: "cancel"},
+	"ready_check_timeout": {Vibrate: []int{200}, LED: "#ff0000", Sound: "cancel"},
+	"element_switch":      {LED: "#ffffff", Sound: "element"},
+	"knockout":            {Vibrate: []int{400, 100, 400}, LED: "#ff0000", Sound: "knockout"},
+	"respawn":             {Vibrate: []int{100}, LED: "#ffffff", Sound: "respawn"},
+	"powerup_spawn":       {Vibrate: []int{60, 60}, LED: "#ffd700", Sound: "powerup"},
+	"powerup_claimed":     {Vibrate: []int{150}, LED: "#ffd700", Sound: "powerup_claimed"},
+	"countdown":           {Vibrate: []int{50}, LED: "#ffffff", Sound: "beep"},
+	"fight_start":         {Vibrate: []int{300}, LED: "#00ff00", Sound: "start"},
+	"game_over":           {LED: "#00ff00", Sound: "win"},
+	"game_over:target":    {Vibrate: []int{500}, LED: "#ff0000", Sound: "lose"},
+}
+
+// 送信待ちのコマンド
+type pendingCommand struct {
+	deviceID string
+	key      string // 設定のキー
+	event    models.GameEvent
+}
+
+type queuedCommand struct {
+	command   DeviceCommand
+	expiresAt time.Time
+}
+
+var (
+	deviceCommandJobs = startDeviceCommandWorker()
+
+	// HTTPのみのデバイスがポーリングで受け取るコマンド
+	deviceCommandQueues   = map[string][]queuedCommand{}
+	deviceCommandQueuesMu sync.Mutex
+
+	// デバイスID → 種類 (登録情報のキャッシュ)
+	deviceTypes   = map[string]string{}
+	deviceTypesMu sync.Mutex
+)
+
+// イベントに対応するコマンドをデバイスに送る (ロック中に呼ばれるため送信は別のgoroutineで行う)
+func dispatchDeviceCommands(event models.GameEvent) {
+	var jobs []pendingCommand
+	for deviceID, binding := range deviceBindings {
+		key := ""
+		switch binding.PlayerID {
+		case event.PlayerID:
+			key = event.Type
+		case event.TargetID:
+			key = event.Type + ":target"
+		}
+		if event.PlayerID == "" && event.TargetID == "" {
+			key = event.Type
+		}
+		if key != "" {
+			jobs = append(jobs, pendingCommand{deviceID: deviceID, key: key, event: event})
+		}
+	}
+
+	for _, job := range jobs {
+		select {
+		case deviceCommandJobs <- job:
+		default:
+			log.Printf("Device command buffer is full, dropping %s for device %s", job.key, job.deviceID)
+		}
+	}
+}
+
+func startDeviceCommandWorker() chan pendingCommand {
+	jobs := make(chan pendingCommand, deviceCommandBuffer)
+	go func() {
+		for job := range jobs {
+			sendDeviceCommand(job)
+		}
+	}()
+	return jobs
+}
+
+// WebSocketで接続中のデバイスには直接送り、それ以外はポーリング用に溜めておく
+func sendDeviceCommand(job pendingCommand) {
+	config, ok := deviceCommandConfig(rules.DeviceCommands, deviceType(job.deviceID), job.key)
+	if !ok {
+		return
+	}
+	command := DeviceCommand{
+		Type:                "command",
+		Event:               job.event.Type,
+		DeviceCommandConfig: config,
+		Value:               job.event.Value,
+		Timestamp:           job.event.Timestamp,
+	}
+
+	mu.Lock()
+	device, connected := devices[job.deviceID]
+	mu.Unlock()
+
+	if connected && device.Conn != nil {
+		if err := device.WriteJSON(command); err != nil {
+			log.Printf("Error sending command to device %s: %v", job.deviceID, err)
+		}
+		return
+	}
+
+	deviceCommandQueuesMu.Lock()
+	defer deviceCommandQueuesMu.Unlock()
+	queue := append(deviceCommandQueues[job.deviceID], queuedCommand{command: command, expiresAt: time.Now().Add(deviceCommandTTL)})
+	if len(queue) > deviceCommandQueueSize {
+		queue = queue[len(queue)-deviceCommandQueueSize:]
+	}
+	deviceCommandQueues[job.deviceID] = queue
+}
+
+// デバイスの種類に対応するコマンドの設定 (commands はルールの設定、なければ既定のコマンド)
+func deviceCommandConfig(commands map[string]map[string]DeviceCommandConfig, deviceType, key string) (DeviceCommandConfig, bool) {
+	if config, ok := commands[deviceType][key]; ok {
+		return config, true
+	}
+	if config, ok := commands["default"][key]; ok {
+		return config, true
+	}
+	config, ok := defaultDeviceCommands[key]
+	return config, ok
+}
+
+// PollDeviceCommands HTTPのみのデバイスに溜まっているコマンドを取り出す
+func PollDeviceCommands(deviceID string) []DeviceCommand {
+	deviceCommandQueuesMu.Lock()
+	defer deviceCommandQueuesMu.Unlock()
+
+	now := time.Now()
+	commands := []DeviceCommand{}
+	for _, q := range deviceCommandQueues[deviceID] {
+		if now.Before(q.expiresAt) {
+			commands = append(commands, q.command)
+		}
+	}
+	delete(deviceCommandQueues, deviceID)
+	return commands
+}
+
+// デバイスの種類を取得 (未登録の場合は空文字列)
+func deviceType(deviceID string) string {
+	deviceTypesMu.Lock()
+	t, cached := deviceTypes[deviceID]
+	deviceTypesMu.Unlock()
+	if cached {
+		return t
+	}
+
+	device, err := repositorys.GetDevice(deviceID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Failed to load device %s: %v", deviceID, err)
+		return ""
+	}
+	if device != nil {
+		t = device.Type
+	}
+
+	deviceTypesMu.Lock()
+	deviceTypes[deviceID] = t
+	deviceTypesMu.Unlock()
+	return t
+}
+
+// 登録情報が変わったらキャッシュを破棄する
+func forgetDeviceType(deviceID string) {
+	deviceTypesMu.Lock()
+	defer deviceTypesMu.Unlock()
+	delete(deviceTypes, deviceID)
+}
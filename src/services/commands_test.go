@@ -0,0 +1,109 @@
+package services
+
+import (
+	"md2s/models"
+	"testing"
+	"time"
+)
+
+func TestDeviceCommandConfig(t *testing.T) {
+	commands := map[string]map[string]DeviceCommandConfig{
+		"glove":   {"hit": {Sound: "glove-hit"}},
+		"default": {"blocked": {Sound: "default-blocked"}},
+	}
+
+	tests := []struct {
+		deviceType, key string
+		wantSound       string
+		wantOK          bool
+	}{
+		{"glove", "hit", "glove-hit", true},
+		{"band", "hit", "hit", true},
+		{"glove", "blocked", "default-blocked", true},
+		{"glove", "hit:target", "damage", true},
+		{"glove", "unknown_event", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.deviceType+" "+tt.key, func(t *testing.T) {
+			config, ok := deviceCommandConfig(commands, tt.deviceType, tt.key)
+			if ok != tt.wantOK || config.Sound != tt.wantSound {
+				t.Errorf("deviceCommandConfig() = %+v, %v, want sound %q, %v", config, ok, tt.wantSound, tt.wantOK)
+			}
+		})
+	}
+}
+
+// ポーリングのキューにコマンドが届くまで待つ
+func waitDeviceCommands(t *testing.T, deviceID string) []DeviceCommand {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if commands := PollDeviceCommands(deviceID); len(commands) > 0 {
+			return commands
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return nil
+}
+
+func TestDispatchDeviceCommands(t *testing.T) {
+	pairTestDevice(t, "test-command-attacker", "player1")
+	pairTestDevice(t, "test-command-target", "player2")
+
+	mu.Lock()
+	dispatchDeviceCommands(models.GameEvent{Type: "hit", PlayerID: "player1", TargetID: "player2", Value: 12})
+	mu.Unlock()
+
+	// 攻撃したプレイヤーのデバイスにはイベントの設定、受けたプレイヤーのデバイスには ":target" の設定
+	tests := []struct {
+		deviceID  string
+		wantSound string
+	}{
+		{"test-command-attacker", "hit"},
+		{"test-command-target", "damage"},
+	}
+	for _, tt := range tests {
+		commands := waitDeviceCommands(t, tt.deviceID)
+		if len(commands) != 1 || commands[0].Event != "hit" || commands[0].Sound != tt.wantSound || commands[0].Value != 12 {
+			t.Errorf("%s received %+v, want one hit command with sound %q", tt.deviceID, commands, tt.wantSound)
+		}
+	}
+
+	// プレイヤーのいないイベントは全てのデバイスに送る
+	mu.Lock()
+	dispatchDeviceCommands(models.GameEvent{Type: "countdown", Value: 3})
+	mu.Unlock()
+	for _, tt := range tests {
+		if commands := waitDeviceCommands(t, tt.deviceID); len(commands) != 1 || commands[0].Event != "countdown" {
+			t.Errorf("%s received %+v, want one countdown command", tt.deviceID, commands)
+		}
+	}
+}
+
+func TestPollDeviceCommands(t *testing.T) {
+	const deviceID = "test-command-poll"
+	defer PollDeviceCommands(deviceID)
+
+	for i := 0; i < deviceCommandQueueSize+5; i++ {
+		sendDeviceCommand(pendingCommand{deviceID: deviceID, key: "countdown", event: models.GameEvent{Type: "countdown", Value: i}})
+	}
+
+	// 溜めておく数を超えたら古いものから捨てる
+	commands := PollDeviceCommands(deviceID)
+	if len(commands) != deviceCommandQueueSize || commands[0].Value != 5 {
+		t.Fatalf("polled %d commands starting at %d, want %d starting at 5", len(commands), commands[0].Value, deviceCommandQueueSize)
+	}
+	if commands := PollDeviceCommands(deviceID); len(commands) != 0 {
+		t.Errorf("second poll returned %d commands, want 0", len(commands))
+	}
+
+	// 期限切れのコマンドは返さない
+	sendDeviceCommand(pendingCommand{deviceID: deviceID, key: "countdown", event: models.GameEvent{Type: "countdown"}})
+	deviceCommandQueuesMu.Lock()
+	deviceCommandQueues[deviceID][0].expiresAt = time.Now().Add(-time.Second)
+	deviceCommandQueuesMu.Unlock()
+	if commands := PollDeviceCommands(deviceID); len(commands) != 0 {
+		t.Errorf("expired commands were returned: %+v", commands)
+	}
+}
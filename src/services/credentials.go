@@ -0,0 +1,224 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"md2s/repositorys"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 署名のタイムスタンプとして許容するずれ (この間はノンスを記憶して再送を防ぐ)
+const signatureMaxSkew = 30 * time.Second
+
+var (
+	ErrDeviceUnauthenticated = errors.New("device authentication required")
+	ErrInvalidSignature      = errors.New("invalid signature")
+	ErrSignatureExpired      = errors.New("signature timestamp out of range")
+	ErrNonceReused           = errors.New("nonce already used")
+)
+
+// デバイスごとの認証失敗の記録
+type DeviceAuthStats struct {
+	DeviceID      string         `json:"deviceId"`
+	Failures      int            `json:"failures"`
+	ByReason      map[string]int `json:"byReason"`
+	LastFailureAt time.Time      `json:"lastFailureAt"`
+}
+
+var (
+	deviceSecrets map[string]string                   // デバイスID → シークレット (最初に使う時に読み込む)
+	usedNonces    = map[string]map[string]time.Time{} // デバイスID → ノンス → 期限
+	authStats     = map[string]*DeviceAuthStats{}
+	credentialsMu sync.Mutex
+)
+
+// IssueDeviceSecret デバイスにシークレットを発行 (以前のシークレットは無効になる)
+func IssueDeviceSecret(deviceID string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	secret := hex.EncodeToString(b)
+
+	// 再起動後も使えるようにデバイスの登録情報に保存する
+	if err := repositorys.UpsertDeviceColumns(deviceID, map[string]interface{}{"secret": secret}); err != nil {
+		return "", err
+	}
+
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+	// 読み込めなかった場合は、次に読み込む時に保存したシークレットが反映される
+	if loadDeviceSecrets() == nil {
+		deviceSecrets[deviceID] = secret
+	}
+	log.Printf("Issued secret for device %s", deviceID)
+	return secret, nil
+}
+
+// RevokeDeviceSecret デバイスのシークレットを無効にする
+func RevokeDeviceSecret(deviceID string) error {
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+	delete(deviceSecrets, deviceID)
+	delete(usedNonces, deviceID)
+	return repositorys.ClearDeviceSecret(deviceID)
+}
+
+// 保存されているシークレットを読み込む (credentialsMu を持って呼ぶ)
+// 読み込めなかった場合は、次に使う時に読み込み直す
+func loadDeviceSecrets() error {
+	if deviceSecrets != nil {
+		return nil
+	}
+	secrets, err := repositorys.GetDeviceSecrets()
+	if err != nil {
+		log.Printf("Failed to load device secrets: %v", err)
+		return err
+	}
+	deviceSecrets = secrets
+	return nil
+}
+
+// DeviceToken WebSocket接続で使うBearerトークン (シークレットから導出する)
+func DeviceToken(deviceID, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("ws:" + deviceID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeviceAuthRequired デバイスの認証が必要かどうか
+// シークレットを発行したデバイス、または DEVICE_AUTH=required の場合は全てのデバイスで必要
+// シークレットを読み込めない場合は、認証なしで受け付けないように必要とする
+func DeviceAuthRequired(deviceID string) bool {
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+	if loadDeviceSecrets() != nil {
+		return true
+	}
+	_, exists := deviceSecrets[deviceID]
+	return exists || os.Getenv("DEVICE_AUTH") == "required"
+}
+
+// DeviceSecret デバイスのシークレットを取得
+func DeviceSecret(deviceID string) (string, bool) {
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+	if loadDeviceSecrets() != nil {
+		return "", false
+	}
+	secret, exists := deviceSecrets[deviceID]
+	return secret, exists
+}
+
+// VerifyDeviceSignature 入力の署名を検証する
+// 署名は hex(HMAC-SHA256(secret, timestamp + "\n" + nonce + "\n" + payload))、timestamp はUNIXミリ秒
+func VerifyDeviceSignature(deviceID, timestamp, nonce, signature string, payload []byte) error {
+	err := verifyDeviceSignature(deviceID, timestamp, nonce, signature, payload)
+	if err != nil {
+		RecordDeviceAuthFailure(deviceID, err)
+	}
+	return err
+}
+
+func verifyDeviceSignature(deviceID, timestamp, nonce, signature string, payload []byte) error {
+	secret, exists := DeviceSecret(deviceID)
+	if !exists || signature == "" || nonce == "" {
+		return ErrDeviceUnauthenticated
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || !ValidSignatureTimestamp(ts) {
+		return ErrSignatureExpired
+	}
+	now := time.Now()
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n"))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+
+	nonces, exists := usedNonces[deviceID]
+	if !exists {
+		nonces = map[string]time.Time{}
+		usedNonces[deviceID] = nonces
+	}
+	for n, expiresAt := range nonces {
+		if now.After(expiresAt) {
+			delete(nonces, n)
+		}
+	}
+	if _, used := nonces[nonce]; used {
+		return ErrNonceReused
+	}
+	// タイムスタンプの許容範囲を過ぎればノンスを覚えておく必要はない
+	nonces[nonce] = now.Add(2 * signatureMaxSkew)
+
+	return nil
+}
+
+// ValidSignatureTimestamp 署名のタイムスタンプ (UNIXミリ秒) が許容範囲内か
+func ValidSignatureTimestamp(ms int64) bool {
+	d := time.Since(time.UnixMilli(ms))
+	return d <= signatureMaxSkew && d >= -signatureMaxSkew
+}
+
+// VerifyDeviceToken WebSocket接続のBearerトークンを検証する
+func VerifyDeviceToken(deviceID, token string) error {
+	secret, exists := DeviceSecret(deviceID)
+	if !exists || token == "" {
+		RecordDeviceAuthFailure(deviceID, ErrDeviceUnauthenticated)
+		return ErrDeviceUnauthenticated
+	}
+	if !hmac.Equal([]byte(DeviceToken(deviceID, secret)), []byte(token)) {
+		RecordDeviceAuthFailure(deviceID, ErrInvalidSignature)
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// RecordDeviceAuthFailure 認証の失敗を記録
+func RecordDeviceAuthFailure(deviceID string, reason error) {
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+
+	stats, exists := authStats[deviceID]
+	if !exists {
+		stats = &DeviceAuthStats{DeviceID: deviceID, ByReason: map[string]int{}}
+		authStats[deviceID] = stats
+	}
+	stats.Failures++
+	stats.ByReason[reason.Error()]++
+	stats.LastFailureAt = time.Now()
+	log.Printf("Device %s failed authentication: %v", deviceID, reason)
+}
+
+// GetDeviceAuthStats 認証失敗の記録を取得
+func GetDeviceAuthStats() []DeviceAuthStats {
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+
+	stats := make([]DeviceAuthStats, 0, len(authStats))
+	for _, s := range authStats {
+		byReason := make(map[string]int, len(s.ByReason))
+		for k, v := range s.ByReason {
+			byReason[k] = v
+		}
+		copied := *s
+		copied.ByReason = byReason
+		stats = append(stats, copied)
+	}
+	return stats
+}
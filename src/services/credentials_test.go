@@ -0,0 +1,90 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// 署名を作る (デバイスのファームウェアと同じ手順)
+func signTestPayload(secret, timestamp, nonce string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n"))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyDeviceSignature(t *testing.T) {
+	const deviceID = "test-signed-device"
+	secret, err := IssueDeviceSecret(deviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DeleteDevice(deviceID)
+
+	payload := []byte(`{"deviceId":"test-signed-device","action":"attack"}`)
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	expired := strconv.FormatInt(time.Now().Add(-2*signatureMaxSkew).UnixMilli(), 10)
+
+	tests := []struct {
+		name      string
+		deviceID  string
+		timestamp string
+		nonce     string
+		signature string
+		want      error
+	}{
+		{"正しい署名", deviceID, now, "n1", signTestPayload(secret, now, "n1", payload), nil},
+		{"ノンスの再利用", deviceID, now, "n1", signTestPayload(secret, now, "n1", payload), ErrNonceReused},
+		{"別のシークレットの署名", deviceID, now, "n2", signTestPayload("other", now, "n2", payload), ErrInvalidSignature},
+		{"期限切れのタイムスタンプ", deviceID, expired, "n3", signTestPayload(secret, expired, "n3", payload), ErrSignatureExpired},
+		{"署名なし", deviceID, now, "n4", "", ErrDeviceUnauthenticated},
+		{"シークレットのないデバイス", "test-unsigned-device", now, "n5", signTestPayload(secret, now, "n5", payload), ErrDeviceUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyDeviceSignature(tt.deviceID, tt.timestamp, tt.nonce, tt.signature, payload); err != tt.want {
+				t.Errorf("VerifyDeviceSignature() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyDeviceToken(t *testing.T) {
+	const deviceID = "test-token-device"
+	secret, err := IssueDeviceSecret(deviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DeleteDevice(deviceID)
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"正しいトークン", DeviceToken(deviceID, secret), nil},
+		{"別のシークレットのトークン", DeviceToken(deviceID, "other"), ErrInvalidSignature},
+		{"トークンなし", "", ErrDeviceUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyDeviceToken(deviceID, tt.token); err != tt.want {
+				t.Errorf("VerifyDeviceToken() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// シークレットを無効にするとトークンも使えない
+	if err := RevokeDeviceSecret(deviceID); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyDeviceToken(deviceID, DeviceToken(deviceID, secret)); err != ErrDeviceUnauthenticated {
+		t.Errorf("VerifyDeviceToken() after revoke = %v, want %v", err, ErrDeviceUnauthenticated)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"math"
+	"md2s/dto"
 	"md2s/models"
 	"time"
 )
@@ -15,6 +17,19 @@ type HttpDevice struct {
 
 var GameOver = false
 
+// HttpProcessInputFromDevice が返す結果
+// "fighting" などは処理の結果を表すためエラーとして返している
+var (
+	ErrInvalidDevice    = errors.New("invalid device ID")
+	ErrGameOver         = errors.New("game over")
+	ErrPlayerNotReady   = errors.New("player not ready")
+	ErrOpponentNotReady = errors.New("opponent not ready")
+	ErrCountdown        = errors.New("countdown")
+	ErrChangeFighting   = errors.New("change fighting")
+	ErrUnknownAction    = errors.New("unknown action")
+	ErrFighting         = errors.New("fighting")
+	ErrStunned          = errors.New("stunned")
+)
 
 // プレイヤーからの入力を処理
 func ProcessInputFromPlayer(playerID string, message []byte) error {
@@ -41,8 +56,23 @@ func ProcessInputFromPlayer(playerID string, message []byte) error {
 	return nil
 }
 
+// ProcessPlayerAction プレイヤーのアクションを更新 (gRPCなどJSON以外の経路用)
+func ProcessPlayerAction(playerID, action string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	player, exists := players[playerID]
+	if !exists {
+		return errors.New("player not found")
+	}
 
-//  デバイスを登録
+	player.Action = action
+	updateGameState()
+
+	return nil
+}
+
+// デバイスを登録
 func HttpRegisterDevice(id string) error {
 	mu.Lock()
 	defer mu.Unlock()
@@ -54,7 +84,7 @@ func HttpRegisterDevice(id string) error {
 	return nil
 }
 
-//  デバイスの登録解除
+// デバイスの登録解除
 func HttpUnregisterDevice(id string) {
 	mu.Lock()
 	defer mu.Unlock()
@@ -63,190 +93,350 @@ func HttpUnregisterDevice(id string) {
 }
 
 // デバイスからの入力を処理
-func HttpProcessInputFromDevice(deviceID, action, state string) error {
+func HttpProcessInputFromDevice(input dto.DeviceInput) error {
+	receivedAt := time.Now()
+	deviceID, state := input.DeviceID, input.State
+
+	// 入力プロファイルでゲームのアクションに変換 (ゲームの状態をロックする前に行う)
+	action := mapInputAction(deviceID, input.Input, input.Action)
+
 	mu.Lock()
 	defer mu.Unlock()
 
 	// デバイスIDに基づいてプレイヤーを判定
 	attacker, target := getPlayersByDevice(deviceID)
 	if attacker == nil || target == nil {
-		return errors.New("invalid device ID")
+		return ErrInvalidDevice
 	}
+	touchDevice(deviceID)
 
+	// 不正の疑いで停止中のデバイス
+	if deviceSuspended(deviceID) {
+		return ErrDeviceSuspended
+	}
+
+	// デバイスの時刻で入力が行われた時刻を求める
+	at := correctedInputTime(deviceID, input.Timestamp, receivedAt)
+
+	// 直前に受けた攻撃より前に防御していた場合は攻撃を取り消す
+	// (勝敗判定より前に行わないと、とどめの攻撃を取り消せない)
+	if action == "defend" && state == "fighting" && attacker.State == "fighting" && !GameOver {
+		compensateLateDefense(attacker, at, inputPower(input.Power))
+	}
 
-		// ゲームオーバーの場合、ゲームを終了
-		if target.HP == 0 || attacker.HP == 0  || GameOver {
+	// ゲームオーバーの場合、ゲームを終了
+	if lobbyPhase() == LobbyFighting && (target.knockedOut() || attacker.knockedOut() || GameOver) {
 
+		log.Printf("Game Over: %v", GameOver)
+		log.Printf("Player %s wins!", attacker.ID)
 
-			log.Printf("Game Over: %v", GameOver)
-			log.Printf("Player %s wins!", attacker.ID)
-	
-			if target.HP == 0 {
-				target.State = "death"
-				attacker.State = "win"
+		if target.knockedOut() {
+			target.State = "death"
+			attacker.State = "win"
+		} else {
+			attacker.State = "death"
+			target.State = "win"
+		}
+
+		if !GameOver {
+			if target.State == "death" {
+				emitGameEvent("game_over", attacker, target, 0)
 			} else {
-				attacker.State = "death"
-				target.State = "win"
+				emitGameEvent("game_over", target, attacker, 0)
 			}
-	
-			GameOver = true
-	
-	
-	
-			updateGameState()
-	
-	
-	
-			return errors.New("game over")
-	
 		}
 
+		GameOver = true
+
+		// 次の試合はロビーの準備確認から始める
+		resetLobby()
+
+		updateGameState()
+
+		return ErrGameOver
+	}
+
+	// ガードブレイク中は行動できない (デバイスから送られた状態で上書きしない)
+	if attacker.State == "guardBroken" {
+		return ErrStunned
+	}
+
+	// 復活を待っている間は行動できない
+	if attacker.State == "respawning" {
+		return ErrRespawning
+	}
+
+	// ロビーが試合を開始するまでは戦闘に入れない
+	if state == "fighting" && lobbyPhase() != LobbyFighting {
+		if GameOver {
+			return ErrGameOver
+		}
+		return ErrPlayerNotReady
+	}
+
 	// stateを更新
+	prevState := attacker.State
 	attacker.State = state
 
+	// 準備完了を取り消した場合、準備確認も取り消す
+	if state == "noReady" && prevState != "noReady" {
+		cancelReadyCheck(attacker, "ready_check_cancel")
+	}
 
 	//　両方のデバイスが初期状態になった場合、初期化
 	if attacker.State == "noReady" && target.State == "noReady" {
 		log.Printf("Player %s is not ready", attacker.ID)
 		GameOver = false
-		return errors.New("player not ready")
+		if lobbyPhase() == LobbyFighting {
+			resetLobby()
+		}
+		return ErrPlayerNotReady
 	}
 
-
 	if attacker.State == "noReady" || attacker.State == "" {
 		log.Printf("Player %s is not ready", attacker.ID)
-		return errors.New("player not ready")
+		return ErrPlayerNotReady
 	}
 
-	// 片方が準備中の場合、actionを無視する
-	if attacker.State == "ready" && target.State == "noReady" || target.State == "" {
-		log.Printf("Player %s is ready, but Player %s is not ready", attacker.ID, target.ID)
-		updateGameState()
-		return errors.New("opponent not ready")
-		
-	}
-
-
-	startCountdown := func() {
-
-		if attacker.Time != 0 || target.Time != 0 {
-
-		// カウントダウンを開始
-			for i := 3; i > 0; i-- {
-				attacker.Time = i
-				target.Time = i
-				updateGameState()
-				time.Sleep(time.Second)
+	// 準備完了はロビーの準備確認に伝え、試合の開始はロビーに任せる
+	if attacker.State == "ready" {
+		if prevState != "ready" {
+			if err := confirmReady(attacker); err != nil {
+				log.Printf("Player %s is ready, but the lobby is %s: %v", attacker.ID, lobbyPhase(), err)
 			}
-			attacker.Time = 0
-			target.Time = 0
-			updateGameState()
 		}
-	}
-
-
-
-	// 準備が完了した場合かつ相手も準備が完了している場合、カウントダウンを開始
-	if attacker.State == "ready" && target.State == "ready" {
-		startCountdown()
-
-		return errors.New("change fighting")
 
+		// 試合が始まるまではカウントダウン中として返す
+		switch lobbyPhase() {
+		case LobbyCountdown:
+			return ErrCountdown
+		case LobbyFighting:
+			return ErrChangeFighting
+		}
+		updateGameState()
+		return ErrOpponentNotReady
 	}
 
-
-
 	if attacker.State == "death" || target.State == "death" {
-
-		return errors.New("game over")
-		
+		return ErrGameOver
 	}
 
+	// ガードブレイク中の相手には攻撃できる
+	if attacker.State == "fighting" && (target.State == "fighting" || target.State == "guardBroken") && !GameOver {
+		// "none" は状態だけを伝える入力 (行動はしない)
+		if action == "none" {
+			updateGameState()
+			return ErrFighting
+		}
 
+		// 人間離れした入力を検知
+		inspectDeviceAction(deviceID, attacker, target, action, at)
 
-	if attacker.State == "fighting" && target.State == "fighting" && !GameOver {
+		// プレイヤーの行動を登録
+		attacker.Action = action
+		attacker.ActionAt = at
+		attacker.Confidence = 1
+		if input.Confidence != nil {
+			attacker.Confidence = clampConfidence(*input.Confidence)
+		}
+		attacker.Power = inputPower(input.Power)
 
-	updateGameState()
-		
-	// プレイヤーの行動を登録
-	attacker.Action = action
+		// 入力で属性を指定した場合は、その属性に切り替えてから行動する (切り替えのMPを消費する)
+		element := inputElement(input.Element)
+		if element != "" && action != "switch_element" {
+			switchElement(attacker, element)
+		}
 
-	// プレイヤーの行動を処理
-	switch action {
-	case "attack":
-		processAttack(attacker, target)
-	case "defend":
-		processDefense(attacker)
-	case "collection":
-		processCollection(attacker)
-	default:
-		log.Printf("Unknown action: %s", action)
-		return errors.New("unknown action")
-	}
+		// プレイヤーの行動を処理
+		// 溜め中に他の行動をすると溜めは無効になる
+		if action != "charge_release" {
+			resetCharge(attacker)
+		}
 
+		switch action {
+		case "attack":
+			processAttack(attacker, target, at)
+		case "charge_start":
+			startCharge(attacker, at)
+		case "charge_release":
+			releaseCharge(attacker, target, at)
+		case "signature":
+			processSignature(attacker, target, at)
+		case "defend":
+			processDefense(attacker)
+		case "collection":
+			processCollection(attacker)
+		case "claim":
+			claimPowerUp(attacker, at)
+		case "switch_element":
+			switchElement(attacker, element)
+		default:
+			log.Printf("Unknown action: %s", action)
+			return ErrUnknownAction
+		}
 
-	// ゲーム状態を更新
-	updateGameState()
+		// ゲーム状態を更新
+		updateGameState()
 
-	return errors.New("fighting")
-}
+		return ErrFighting
+	}
 
-     updateGameState()    
+	updateGameState()
 
 	return nil
 }
 
-
-
 // GetGameState 現在のゲーム状態を取得
 func GetGameState() models.GameState {
 	mu.Lock()
 	defer mu.Unlock()
-	return models.GameState{
-		Player1HP:     players["player1"].HP,
-		Player1MP:     players["player1"].MP,
-		Player1DF:     players["player1"].DF,
-		Player1Action: players["player1"].Action,
-		Player1State: players["player1"].State,
-		Player2HP:     players["player2"].HP,
-		Player2MP:     players["player2"].MP,
-		Player2DF:     players["player2"].DF,
-		Player2Action: players["player2"].Action,
-		Player2State: players["player2"].State,
+	return buildGameState()
+}
+
+// GetDevicePlayerStatus デバイスに対応するプレイヤーのHPとMPを取得
+func GetDevicePlayerStatus(deviceID string) (hp, mp int, ok bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	player, _ := getPlayersByDevice(deviceID)
+	if player == nil {
+		return 0, 0, false
 	}
+	return player.HP, player.MP, true
 }
 
-// デバイスIDに対応するプレイヤーを取得
+// デバイスIDに対応するプレイヤーと対戦相手を取得 (ペアリングされていない場合は nil)
 func getPlayersByDevice(deviceID string) (*Player, *Player) {
-	if deviceID == "1" {
-		return players["player1"], players["player2"]
+	slot, ok := playerSlotByDevice(deviceID)
+	if !ok {
+		return nil, nil
 	}
-	if deviceID == "2" {
-		return players["player2"], players["player1"]
-	}
-	return nil, nil
+	return players[slot], players[opponentSlots[slot]]
 }
 
-// 攻撃処理
-func processAttack(attacker, target *Player) {
+// 攻撃処理 (at は補正後の攻撃時刻)
+func processAttack(attacker, target *Player, at time.Time) {
 	if attacker.MP == 0 {
 		log.Printf("Player %s has no MP", attacker.ID)
+		emitGameEvent("no_mp", attacker, target, 0)
+		return
+	}
+
+	resolveAttack(attacker, target, at, attacker.attackDamage(), arenaCost(attacker.class.AttackCost, arenaAttackCost))
+}
+
+// 攻撃の結果を処理 (base は強さと防御を考慮する前のダメージ、mpCost は命中した場合に消費するMP)
+func resolveAttack(attacker, target *Player, at time.Time, base, mpCost int) {
+	// 復活後の無敵時間中は攻撃を受けない (MPも消費しない)
+	if target.invulnerable() {
+		log.Printf("Player %s is invulnerable", target.ID)
+		emitGameEvent("invulnerable", attacker, target, 0)
+		return
+	}
+
+	// 防御した時刻によってパリィ・防御・遅れた防御のいずれかになる
+	// ガードブレイク中は防御できず、ダメージが増える
+	defense := defenseNone
+	if target.Action == "defend" && target.State != "guardBroken" {
+		defense = defenseOutcome(target.ActionAt, at)
+	}
+
+	// 属性の相性でダメージと防御で防げる割合が変わる
+	matchup := elementMatchup(attacker.Element, target.Element)
+	damage := int(math.Round(float64(base) * rules.Power.Damage.apply(attacker.Power) * matchup.Damage *
+		arenaFactor(arenaDamage) * powerUpDamageFactor(attacker, target)))
+	if target.State == "guardBroken" {
+		damage = int(math.Round(float64(damage) * rules.GuardBreak.DamageMultiplier))
+	}
+	switch defense {
+	case defenseParry:
+		parry(target, attacker, damage)
 		return
+	case defenseBlock:
+		// 弱い防御はダメージの一部しか防げない
+		damage -= int(math.Round(float64(damage) * matchup.blockRatio(blockRatio(target.Power))))
+	case defenseLate:
+		damage -= int(math.Round(float64(damage) * matchup.blockRatio(rules.LateDefenseReduction)))
 	}
-	if target.Action == "defend" {
+
+	if defense != defenseNone && damage <= 0 {
 		log.Printf("Player %s's attack was blocked by Player %s's defense!", attacker.ID, target.ID)
+		emitGameEvent("blocked", attacker, target, 0)
 	} else {
-		damage := attacker.MP/5
+		hp, mp := target.HP, attacker.MP
 		target.HP -= damage
-		attacker.MP -= 20
+		attacker.MP -= mpCost
 		if attacker.MP < 0 {
 			attacker.MP = 0
 		}
 		if target.HP < 0 {
 			target.HP = 0
 		}
+		// 遅れて届いた防御で取り消せるように記録しておく
+		target.lastHit = &hitRecord{
+			attacker:   attacker,
+			hpLost:     hp - target.HP,
+			mpSpent:    mp - attacker.MP,
+			matchup:    matchup,
+			at:         at,
+			receivedAt: time.Now(),
+		}
 		log.Printf("Player %s attacked Player %s for %d damage", attacker.ID, target.ID, damage)
+		emitGameEvent("hit", attacker, target, damage)
+
+		// 攻撃を受けると溜めは中断される
+		if damage > 0 {
+			interruptCharge(target)
+		}
+		knockOut(target, attacker)
+	}
+}
+
+// 入力の強さを 0〜1 に丸める (省略時は最大)
+func inputPower(power *float64) float64 {
+	if power == nil || math.IsNaN(*power) {
+		return 1
 	}
+	return math.Max(0, math.Min(1, *power))
+}
+
+// 遅れて届いた防御の補正
+// 補正後の防御時刻が直前の攻撃時刻から許容範囲内であれば、攻撃を受ける前に防御していたとみなす
+// パリィであれば攻撃を取り消して反撃し、それ以外はダメージの一部または全部を取り消す
+func compensateLateDefense(player *Player, at time.Time, power float64) {
+	hit := player.lastHit
+	player.lastHit = nil
+	if hit == nil || player.DF == 0 {
+		return
+	}
+
+	// 攻撃を受けてから時間が経ちすぎている
+	if time.Since(hit.receivedAt) > rules.lagTolerance()+rules.maxLagCompensation() {
+		return
+	}
+
+	var blocked int
+	switch defenseOutcome(at, hit.at) {
+	case defenseNone:
+		return
+	case defenseParry:
+		player.HP += hit.hpLost
+		hit.attacker.MP += hit.mpSpent
+		log.Printf("Player %s's late defense parried Player %s's attack (lag compensated)", player.ID, hit.attacker.ID)
+		parry(player, hit.attacker, hit.hpLost)
+		return
+	case defenseBlock:
+		blocked = int(math.Round(float64(hit.hpLost) * hit.matchup.blockRatio(blockRatio(power))))
+	case defenseLate:
+		blocked = int(math.Round(float64(hit.hpLost) * hit.matchup.blockRatio(rules.LateDefenseReduction)))
+	}
+	player.HP += blocked
+	if blocked < hit.hpLost {
+		log.Printf("Player %s's late defense partially blocked Player %s's attack (lag compensated)", player.ID, hit.attacker.ID)
+		return
+	}
+	hit.attacker.MP += hit.mpSpent
+	log.Printf("Player %s's late defense blocked Player %s's attack (lag compensated)", player.ID, hit.attacker.ID)
+	emitGameEvent("blocked", hit.attacker, player, 0)
 }
 
 // 防御処理
@@ -255,48 +445,88 @@ func processDefense(player *Player) {
 	if player.DF == 0 {
 		log.Printf("Player %s has no DF", player.ID)
 		player.Action = "none"
+		breakGuard(player)
 		return
 	}
 
-	player.DF -= 10
+	player.DF -= arenaCost(player.class.DefendCost, arenaDefendCost)
 	if player.DF < 0 {
 		player.DF = 0
 	}
 	log.Printf("Player %s is defending", player.ID)
+	emitGameEvent("defend", player, nil, player.DF)
+
+	// DFを使い切るとガードブレイク
+	if player.DF == 0 {
+		player.Action = "none"
+		breakGuard(player)
+	}
 }
 
 // MP回復処理
 func processCollection(player *Player) {
-	player.MP += 10
-	if player.MP > 100 {
-		player.MP = 100
+	player.MP += int(math.Round(float64(player.class.CollectionAmount) * rules.Power.Collection.apply(player.Power) * arenaFactor(arenaCollection)))
+	if player.MP > player.class.MP {
+		player.MP = player.class.MP
 	}
 	log.Printf("Player %s collected MP", player.ID)
+	emitGameEvent("collection", player, nil, player.MP)
+}
+
+// ゲーム状態を作成 (参加していないスロットはゼロ値)
+func buildGameState() models.GameState {
+	gameState := models.GameState{Phase: lobbyPhase()}
+	if p1 := players["player1"]; p1 != nil {
+		gameState.Player1HP = p1.HP
+		gameState.Player1MP = p1.MP
+		gameState.Player1DF = p1.DF
+		gameState.Player1Action = p1.Action
+		gameState.Player1State = p1.State
+		gameState.Player1Confidence = p1.Confidence
+		gameState.Player1Charge = p1.Charge
+		gameState.Player1Class = p1.Class
+		gameState.Player1Buffs = p1.buffs()
+		gameState.Player1Element = p1.Element
+		gameState.Player1Stocks = p1.Stocks
+		gameState.Player1RespawnMs = p1.respawnMs()
+		gameState.Player1InvulnerableMs = p1.invulnerableMs()
+		gameState.Time = p1.Time
+	}
+	if p2 := players["player2"]; p2 != nil {
+		gameState.Player2HP = p2.HP
+		gameState.Player2MP = p2.MP
+		gameState.Player2DF = p2.DF
+		gameState.Player2Action = p2.Action
+		gameState.Player2State = p2.State
+		gameState.Player2Confidence = p2.Confidence
+		gameState.Player2Charge = p2.Charge
+		gameState.Player2Class = p2.Class
+		gameState.Player2Buffs = p2.buffs()
+		gameState.Player2Element = p2.Element
+		gameState.Player2Stocks = p2.Stocks
+		gameState.Player2RespawnMs = p2.respawnMs()
+		gameState.Player2InvulnerableMs = p2.invulnerableMs()
+	}
+	gameState.ArenaModifiers = arena.modifiers
+	if powerUps.active != nil {
+		gameState.PowerUp = powerUps.active.kind
+	}
+	return gameState
 }
 
 // ゲーム状態を更新
 func updateGameState() {
-	gameState := models.GameState{
-		Player1HP:     players["player1"].HP,
-		Player1MP:     players["player1"].MP,
-		Player1DF:     players["player1"].DF,
-		Player1Action: players["player1"].Action,
-		Player1State: players["player1"].State,
-		Player2HP:     players["player2"].HP,
-		Player2MP:     players["player2"].MP,
-		Player2DF:     players["player2"].DF,
-		Player2Action: players["player2"].Action,
-		Player2State: players["player2"].State,
-		Time:          players["player1"].Time,
-	}
+	gameState := buildGameState()
 
 	// プレイヤーにブロードキャスト
 	for _, player := range players {
 		if player.Conn != nil {
-			err := player.Conn.WriteJSON(gameState)
+			err := writeGameMessage(player.Conn, models.GameMessage{State: &gameState})
 			if err != nil {
 				log.Printf("Error sending game state to player %s: %v", player.ID, err)
 			}
 		}
 	}
+
+	notifyGameStateListeners(gameState)
 }
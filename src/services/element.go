@@ -0,0 +1,88 @@
+package services
+
+import (
+	"log"
+	"math"
+	"slices"
+)
+
+// ElementMatchup 攻撃の属性と防御側の属性の相性
+type ElementMatchup struct {
+	Damage float64 `json:"damage"` // ダメージの倍率
+	Block  float64 `json:"block"`  // 防御で防ぐダメージの割合の倍率
+}
+
+var (
+	elementStrong  = ElementMatchup{Damage: 1.5, Block: 0.5}
+	elementWeak    = ElementMatchup{Damage: 0.75, Block: 1.5}
+	elementNeutral = ElementMatchup{Damage: 1, Block: 1}
+)
+
+// 既定の相性表 (火は風に、風は水に、水は火に強い)
+var defaultElementMatchups = map[string]map[string]ElementMatchup{
+	"fire":  {"wind": elementStrong, "water": elementWeak},
+	"water": {"fire": elementStrong, "wind": elementWeak},
+	"wind":  {"water": elementStrong, "fire": elementWeak},
+}
+
+// 攻撃の属性と防御側の属性の相性 (どちらかが属性なしの場合や表にない組み合わせは等倍)
+func elementMatchup(attack, defense string) ElementMatchup {
+	if m, ok := rules.Elements.Matchups[attack][defense]; ok {
+		return m
+	}
+	if m, ok := defaultElementMatchups[attack][defense]; ok {
+		return m
+	}
+	return elementNeutral
+}
+
+// 使える属性か
+func validElement(element string) bool {
+	if slices.Contains(rules.Elements.Order, element) {
+		return true
+	}
+	_, ok := rules.Elements.Matchups[element]
+	if !ok {
+		_, ok = defaultElementMatchups[element]
+	}
+	return ok
+}
+
+// 入力で指定された属性 (使えない属性は無視してプレイヤーの属性にする)
+func inputElement(element string) string {
+	if element == "" || validElement(element) {
+		return element
+	}
+	log.Printf("Unknown element: %s", element)
+	return ""
+}
+
+// 相性を反映した、防御で防ぐダメージの割合
+func (m ElementMatchup) blockRatio(ratio float64) float64 {
+	return math.Min(1, ratio*m.Block)
+}
+
+// 属性を切り替える (element が空の場合は切り替える順番の次の属性にする)
+func switchElement(player *Player, element string) {
+	if element == "" {
+		order := rules.Elements.Order
+		if len(order) == 0 {
+			log.Printf("Player %s tried to switch element, but no element order is configured", player.ID)
+			return
+		}
+		element = order[(slices.Index(order, player.Element)+1)%len(order)]
+	}
+	if element == player.Element {
+		return
+	}
+	if player.MP < rules.Elements.SwitchCost {
+		log.Printf("Player %s has not enough MP to switch element", player.ID)
+		emitGameEvent("no_mp", player, nil, 0)
+		return
+	}
+
+	player.MP -= rules.Elements.SwitchCost
+	player.Element = element
+	log.Printf("Player %s switched element to %s", player.ID, element)
+	emitGameEvent("element_switch", player, nil, player.MP)
+}
@@ -0,0 +1,66 @@
+package services
+
+import "testing"
+
+func TestElementMatchup(t *testing.T) {
+	tests := []struct {
+		attack, defense string
+		want            ElementMatchup
+	}{
+		{"fire", "wind", elementStrong},
+		{"wind", "water", elementStrong},
+		{"water", "fire", elementStrong},
+		{"fire", "water", elementWeak},
+		{"wind", "fire", elementWeak},
+		{"water", "wind", elementWeak},
+		{"fire", "fire", elementNeutral},
+		{"", "fire", elementNeutral},
+		{"fire", "", elementNeutral},
+		{"unknown", "fire", elementNeutral},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.attack+"_vs_"+tt.defense, func(t *testing.T) {
+			if got := elementMatchup(tt.attack, tt.defense); got != tt.want {
+				t.Errorf("elementMatchup(%q, %q) = %+v, want %+v", tt.attack, tt.defense, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwitchElement(t *testing.T) {
+	cost := rules.Elements.SwitchCost
+
+	tests := []struct {
+		name        string
+		current     string
+		target      string
+		mp          int
+		wantElement string
+		wantMP      int
+	}{
+		{"switch", "fire", "water", 100, "water", 100 - cost},
+		{"cycle to next", "fire", "", 100, "water", 100 - cost},
+		{"cycle wraps around", "wind", "", 100, "fire", 100 - cost},
+		{"cycle from no element", "", "", 100, "fire", 100 - cost},
+		{"same element is free", "fire", "fire", 100, "fire", 100},
+		{"not enough MP", "fire", "water", cost - 1, "fire", cost - 1},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := newTestPlayer("player1")
+			player.Element = tt.current
+			player.MP = tt.mp
+
+			switchElement(player, tt.target)
+
+			if player.Element != tt.wantElement || player.MP != tt.wantMP {
+				t.Errorf("element, MP = %s, %d, want %s, %d", player.Element, player.MP, tt.wantElement, tt.wantMP)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"bytes"
+	"md2s/models"
+	"md2s/pb"
+	"slices"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// WebSocketのサブプロトコルで指定できるエンコーディング
+// json はサブプロトコルの指定がない場合と同じく、ゲーム状態のJSONのみを送る
+// それ以外は {"state": ...} / {"event": ...} の形式でゲーム状態とイベントを送る
+const (
+	EncodingJSON         = "json"
+	EncodingJSONEnvelope = "json.envelope"
+	EncodingMsgpack      = "msgpack"
+	EncodingProtobuf     = "protobuf"
+)
+
+// Subprotocols サーバーが対応するサブプロトコル
+var Subprotocols = []string{EncodingJSON, EncodingJSONEnvelope, EncodingMsgpack, EncodingProtobuf}
+
+// SelectSubprotocol クライアントが提示したサブプロトコルのうち、対応しているものを提示順で選ぶ
+// 対応しているものがなければ空文字
+func SelectSubprotocol(requested []string) string {
+	for _, protocol := range requested {
+		if slices.Contains(Subprotocols, protocol) {
+			return protocol
+		}
+	}
+	return ""
+}
+
+// 接続で合意したエンコーディングでメッセージを送信
+// サブプロトコルの指定がない接続と json の接続には従来通りゲーム状態のJSONのみを送る
+func writeGameMessage(conn *websocket.Conn, msg models.GameMessage) error {
+	switch conn.Subprotocol() {
+	case EncodingJSONEnvelope:
+		return conn.WriteJSON(msg)
+	case EncodingMsgpack:
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetCustomStructTag("json")
+		enc.UseCompactInts(true)
+		if err := enc.Encode(msg); err != nil {
+			return err
+		}
+		return conn.WriteMessage(websocket.BinaryMessage, buf.Bytes())
+	case EncodingProtobuf:
+		b, err := proto.Marshal(pb.FromGameMessage(msg))
+		if err != nil {
+			return err
+		}
+		return conn.WriteMessage(websocket.BinaryMessage, b)
+	default:
+		if msg.State == nil {
+			return nil
+		}
+		return conn.WriteJSON(msg.State)
+	}
+}
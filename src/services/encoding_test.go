@@ -0,0 +1,160 @@
+package services
+
+import (
+	"encoding/json"
+	"md2s/models"
+	"md2s/pb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSelectSubprotocol(t *testing.T) {
+	tests := []struct {
+		requested []string
+		want      string
+	}{
+		{nil, ""},
+		{[]string{"unknown"}, ""},
+		{[]string{"msgpack", "json"}, EncodingMsgpack},
+		{[]string{"json", "msgpack"}, EncodingJSON},
+		{[]string{"unknown", "protobuf"}, EncodingProtobuf},
+		{[]string{"json.envelope"}, EncodingJSONEnvelope},
+	}
+
+	for _, tt := range tests {
+		if got := SelectSubprotocol(tt.requested); got != tt.want {
+			t.Errorf("SelectSubprotocol(%v) = %q, want %q", tt.requested, got, tt.want)
+		}
+	}
+}
+
+// subprotocol で接続して msg を1つ送り、受信したメッセージを返す (受信しなければ nil)
+func receiveGameMessage(t *testing.T, subprotocol string, msg models.GameMessage) (int, []byte) {
+	t.Helper()
+	upgrader := websocket.Upgrader{Subprotocols: Subprotocols}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if err := writeGameMessage(conn, msg); err != nil {
+			t.Errorf("writeGameMessage() error = %v", err)
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte("end"))
+	}))
+	defer server.Close()
+
+	dialer := websocket.Dialer{}
+	if subprotocol != "" {
+		dialer.Subprotocols = []string{subprotocol}
+	}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) == "end" {
+		return 0, nil
+	}
+	return messageType, data
+}
+
+func TestWriteGameMessage(t *testing.T) {
+	state := models.GameMessage{State: &models.GameState{Player1HP: 42, Phase: LobbyFighting}}
+	event := models.GameMessage{Event: &models.GameEvent{Type: "hit", PlayerID: "player1", Value: 7}}
+
+	tests := []struct {
+		name        string
+		subprotocol string
+		msg         models.GameMessage
+		decode      func(t *testing.T, messageType int, data []byte) models.GameMessage
+	}{
+		{"no subprotocol sends bare state", "", state, decodeBareState},
+		{"json sends bare state", EncodingJSON, state, decodeBareState},
+		{"json envelope state", EncodingJSONEnvelope, state, decodeEnvelope},
+		{"json envelope event", EncodingJSONEnvelope, event, decodeEnvelope},
+		{"msgpack state", EncodingMsgpack, state, decodeMsgpack},
+		{"msgpack event", EncodingMsgpack, event, decodeMsgpack},
+		{"protobuf state", EncodingProtobuf, state, decodeProtobuf},
+		{"protobuf event", EncodingProtobuf, event, decodeProtobuf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messageType, data := receiveGameMessage(t, tt.subprotocol, tt.msg)
+			if data == nil {
+				t.Fatalf("no message received")
+			}
+			got := tt.decode(t, messageType, data)
+			if tt.msg.State != nil && (got.State == nil || got.State.Player1HP != 42 || got.State.Phase != LobbyFighting) {
+				t.Errorf("state = %+v, want %+v", got.State, tt.msg.State)
+			}
+			if tt.msg.Event != nil && (got.Event == nil || *got.Event != *tt.msg.Event) {
+				t.Errorf("event = %+v, want %+v", got.Event, tt.msg.Event)
+			}
+		})
+	}
+}
+
+func TestWriteGameMessageSkipsEventsForBareJSON(t *testing.T) {
+	event := models.GameMessage{Event: &models.GameEvent{Type: "hit"}}
+	for _, subprotocol := range []string{"", EncodingJSON} {
+		if _, data := receiveGameMessage(t, subprotocol, event); data != nil {
+			t.Errorf("subprotocol %q: received %s, want nothing", subprotocol, data)
+		}
+	}
+}
+
+func decodeBareState(t *testing.T, messageType int, data []byte) models.GameMessage {
+	var state models.GameState
+	if messageType != websocket.TextMessage || json.Unmarshal(data, &state) != nil {
+		t.Fatalf("invalid bare state: %s", data)
+	}
+	return models.GameMessage{State: &state}
+}
+
+func decodeEnvelope(t *testing.T, messageType int, data []byte) models.GameMessage {
+	var msg models.GameMessage
+	if messageType != websocket.TextMessage || json.Unmarshal(data, &msg) != nil {
+		t.Fatalf("invalid envelope: %s", data)
+	}
+	return msg
+}
+
+func decodeMsgpack(t *testing.T, messageType int, data []byte) models.GameMessage {
+	var msg models.GameMessage
+	dec := msgpack.NewDecoder(strings.NewReader(string(data)))
+	dec.SetCustomStructTag("json")
+	if messageType != websocket.BinaryMessage || dec.Decode(&msg) != nil {
+		t.Fatalf("invalid msgpack message")
+	}
+	return msg
+}
+
+func decodeProtobuf(t *testing.T, messageType int, data []byte) models.GameMessage {
+	var res pb.WatchResponse
+	if messageType != websocket.BinaryMessage || proto.Unmarshal(data, &res) != nil {
+		t.Fatalf("invalid protobuf message")
+	}
+	if e := res.GetEvent(); e != nil {
+		return models.GameMessage{Event: &models.GameEvent{
+			Type: e.Type, PlayerID: e.PlayerId, TargetID: e.TargetId, Value: int(e.Value), Timestamp: e.Timestamp,
+		}}
+	}
+	s := res.GetState()
+	return models.GameMessage{State: &models.GameState{Player1HP: int(s.Player1Hp), Phase: s.Phase}}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"md2s/dto"
+	"md2s/models"
+	"md2s/repositorys"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// デバイスのグループが未設定の場合のグループ
+const defaultDeviceGroup = "default"
+
+var (
+	ErrInvalidFirmware        = errors.New("device type and version may only contain letters, digits, '.', '-' and '_'")
+	ErrInvalidFirmwareVersion = errors.New("version must be numbers separated by '.' (e.g. 1.2.3)")
+	ErrFirmwareExists         = errors.New("firmware version already exists")
+	ErrInvalidPercentage      = errors.New("percentage must be between 0 and 100")
+)
+
+var (
+	firmwareNamePattern    = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+	firmwareVersionPattern = regexp.MustCompile(`^v?[0-9]+(\.[0-9]+)*$`)
+)
+
+// ファームウェアのイメージを保存するディレクトリ
+func firmwareDir() string {
+	if dir := os.Getenv("FIRMWARE_DIR"); dir != "" {
+		return dir
+	}
+	return "./firmware"
+}
+
+// UploadFirmware ファームウェアのイメージを保存して登録する
+// 登録済みのバージョンは上書きしない (配信中のイメージが壊れないように、別のバージョンとして登録する)
+func UploadFirmware(deviceType, version, notes string, image io.Reader) (*models.Firmware, error) {
+	if !firmwareNamePattern.MatchString(deviceType) || !firmwareNamePattern.MatchString(version) {
+		return nil, ErrInvalidFirmware
+	}
+	if !firmwareVersionPattern.MatchString(version) {
+		return nil, ErrInvalidFirmwareVersion
+	}
+	if _, err := repositorys.GetFirmwareByVersion(deviceType, version); err == nil {
+		return nil, ErrFirmwareExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(firmwareDir(), 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(firmwareDir(), fmt.Sprintf("%s-%s.bin", deviceType, version))
+
+	// 一時ファイルに書き込み、登録できてからイメージの場所に移す
+	file, err := os.CreateTemp(firmwareDir(), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := file.Name()
+	defer os.Remove(tmpPath)
+
+	// 書き込みながらチェックサムを計算
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), image)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	firmware := &models.Firmware{
+		DeviceType: deviceType,
+		Version:    version,
+		Checksum:   hex.EncodeToString(hash.Sum(nil)),
+		Size:       size,
+		FilePath:   path,
+		Notes:      notes,
+	}
+	// 同時に同じバージョンが登録された場合は UNIQUE 制約で失敗する
+	if err := repositorys.CreateFirmware(firmware); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		if err := repositorys.DeleteFirmware(firmware.ID); err != nil {
+			log.Printf("Failed to delete firmware %d: %v", firmware.ID, err)
+		}
+		return nil, err
+	}
+
+	log.Printf("Firmware %s %s uploaded (%d bytes)", deviceType, version, size)
+	return firmware, nil
+}
+
+// ListFirmwares 登録されているファームウェアの一覧を取得
+func ListFirmwares() ([]models.Firmware, error) {
+	return repositorys.GetFirmwares()
+}
+
+// GetFirmware ファームウェアを取得
+func GetFirmware(firmwareID int) (*models.Firmware, error) {
+	return repositorys.GetFirmware(firmwareID)
+}
+
+// DeleteFirmware ファームウェアを削除
+func DeleteFirmware(firmwareID int) error {
+	firmware, err := repositorys.GetFirmware(firmwareID)
+	if err != nil {
+		return err
+	}
+	if err := repositorys.DeleteFirmware(firmwareID); err != nil {
+		return err
+	}
+	if err := os.Remove(firmware.FilePath); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove firmware image %s: %v", firmware.FilePath, err)
+	}
+	return nil
+}
+
+// ListFirmwareRollouts ファームウェアの配信割合の一覧を取得
+func ListFirmwareRollouts(firmwareID int) ([]models.FirmwareRollout, error) {
+	return repositorys.GetFirmwareRollouts(firmwareID)
+}
+
+// SetFirmwareRollout デバイスグループへの配信割合を設定 (0 で配信停止、100 で全台)
+func SetFirmwareRollout(firmwareID int, group string, percentage int) (*models.FirmwareRollout, error) {
+	if percentage < 0 || percentage > 100 {
+		return nil, ErrInvalidPercentage
+	}
+	if group == "" {
+		group = defaultDeviceGroup
+	}
+	if _, err := repositorys.GetFirmware(firmwareID); err != nil {
+		return nil, err
+	}
+
+	rollout := &models.FirmwareRollout{FirmwareID: firmwareID, Group: group, Percentage: percentage}
+	if err := repositorys.SaveFirmwareRollout(rollout); err != nil {
+		return nil, err
+	}
+	return rollout, nil
+}
+
+// CheckFirmwareUpdate デバイスに配信すべきファームウェアを取得 (更新がなければ nil)
+// デバイスの種類とグループは登録情報を優先し、未登録の場合は deviceType を使う
+func CheckFirmwareUpdate(deviceID, currentVersion, deviceType string) (*models.Firmware, error) {
+	group := defaultDeviceGroup
+	device, err := repositorys.GetDevice(deviceID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if device != nil {
+		if device.Type != "" {
+			deviceType = device.Type
+		}
+		if device.Group != "" {
+			group = device.Group
+		}
+	}
+
+	firmwares, percentages, err := repositorys.GetRolledOutFirmwares(deviceType, group)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *models.Firmware
+	for i := range firmwares {
+		firmware := &firmwares[i]
+		if compareVersions(firmware.Version, currentVersion) <= 0 {
+			continue
+		}
+		if !inRollout(deviceID, firmware.ID, percentages[firmware.ID]) {
+			continue
+		}
+		if latest == nil || compareVersions(firmware.Version, latest.Version) > 0 {
+			latest = firmware
+		}
+	}
+	return latest, nil
+}
+
+// ReportFirmwareUpdate デバイスからのファームウェア更新の結果を記録
+func ReportFirmwareUpdate(report dto.FirmwareUpdateReport) error {
+	values := map[string]interface{}{
+		"last_update_version": report.Version,
+		"last_update_status":  "failed",
+		"last_update_error":   report.Error,
+		"last_update_at":      time.Now(),
+		"last_seen_at":        time.Now(),
+	}
+	if report.Success {
+		values["last_update_status"] = "success"
+		values["firmware_version"] = report.Version
+	}
+
+	if !report.Success {
+		log.Printf("Firmware update of device %s to %s failed: %s", report.DeviceID, report.Version, report.Error)
+	}
+	return repositorys.UpsertDeviceColumns(report.DeviceID, values)
+}
+
+// デバイスが配信割合に含まれるか
+// デバイスIDとファームウェアから決まるので、割合を増やしても既に配信されたデバイスは外れない
+func inRollout(deviceID string, firmwareID, percentage int) bool {
+	h := fnv.New32a()
+	h.Write([]byte(deviceID + ":" + strconv.Itoa(firmwareID)))
+	return int(h.Sum32()%100) < percentage
+}
+
+// "1.2.10" のようなバージョンを比較 (a > b なら正、a < b なら負)
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if c := compareVersionSegments(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// バージョンの区切りごとの比較 ("3", "3-beta" など)
+// 先頭の数字を数値で比べ、同じ場合は後ろに文字があるもの (プレリリース) を前とし、文字同士は辞書順で比べる
+func compareVersionSegments(a, b string) int {
+	an, as := splitVersionSegment(a)
+	bn, bs := splitVersionSegment(b)
+	if an != bn {
+		if an < bn {
+			return -1
+		}
+		return 1
+	}
+	switch {
+	case as == bs:
+		return 0
+	case as == "":
+		return 1
+	case bs == "":
+		return -1
+	}
+	return strings.Compare(as, bs)
+}
+
+func splitVersionSegment(segment string) (int, string) {
+	i := 0
+	for i < len(segment) && segment[i] >= '0' && segment[i] <= '9' {
+		i++
+	}
+	n, _ := strconv.Atoi(segment[:i])
+	return n, segment[i:]
+}
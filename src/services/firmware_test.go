@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.10", "1.2.9", 1},
+		{"1.2", "1.2.0", 0},
+		{"v1.3", "1.2.9", 1},
+		{"2.0", "10.0", -1},
+		{"1.0.0-beta", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if got := compareVersions(tt.a, tt.b); got != tt.want {
+				t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := compareVersions(tt.b, tt.a); got != -tt.want {
+				t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestInRollout(t *testing.T) {
+	const firmwareID = 7
+	deviceIDs := make([]string, 1000)
+	for i := range deviceIDs {
+		deviceIDs[i] = fmt.Sprintf("device-%d", i)
+	}
+
+	for _, id := range deviceIDs {
+		if inRollout(id, firmwareID, 0) {
+			t.Fatalf("%s is in a 0%% rollout", id)
+		}
+		if !inRollout(id, firmwareID, 100) {
+			t.Fatalf("%s is not in a 100%% rollout", id)
+		}
+	}
+
+	// 割合を増やしても、既に含まれていたデバイスは外れない
+	previous := 0
+	for _, percentage := range []int{10, 25, 50} {
+		count := 0
+		for _, id := range deviceIDs {
+			if !inRollout(id, firmwareID, percentage) {
+				continue
+			}
+			count++
+			for _, larger := range []int{percentage + 1, 100} {
+				if !inRollout(id, firmwareID, larger) {
+					t.Fatalf("%s left the rollout when it grew from %d%% to %d%%", id, percentage, larger)
+				}
+			}
+		}
+		if count < previous {
+			t.Errorf("%d%% rollout has %d devices, fewer than the previous %d", percentage, count, previous)
+		}
+		// 1000台なので大きく外れないはず
+		if want := percentage * 10; count < want/2 || count > want*3/2 {
+			t.Errorf("%d%% rollout has %d of 1000 devices, want about %d", percentage, count, want)
+		}
+		previous = count
+	}
+}
+
+func TestUploadFirmwareValidation(t *testing.T) {
+	tests := []struct {
+		deviceType, version string
+		want                error
+	}{
+		{"glove", "../1.0", ErrInvalidFirmware},
+		{"glove/../../etc", "1.0", ErrInvalidFirmware},
+		{"glove", "1.0-beta", ErrInvalidFirmwareVersion},
+		{"glove", "latest", ErrInvalidFirmwareVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.deviceType+" "+tt.version, func(t *testing.T) {
+			if _, err := UploadFirmware(tt.deviceType, tt.version, "", strings.NewReader("image")); err != tt.want {
+				t.Errorf("UploadFirmware() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
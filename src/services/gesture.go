@@ -0,0 +1,338 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"md2s/dto"
+	"md2s/models"
+	"os"
+	"sync"
+)
+
+// DTWで比較する前にサンプル数をそろえる長さ
+const gestureResampleLength = 32
+
+// GestureRecognizer 1つの動作のサンプルからアクションと確からしさ (0〜1) を求める
+type GestureRecognizer interface {
+	Recognize(samples []models.IMUSample) (action string, confidence float64)
+}
+
+// GestureResult 認識したジェスチャー
+type GestureResult struct {
+	Action     string  `json:"action"`
+	Confidence float64 `json:"confidence"`
+	// 入力として処理した結果 (確からしさが足りない場合は "low confidence"、記録中は "recorded")
+	Result string `json:"result"`
+}
+
+// 動作の区切りを検出するためのデバイスごとの状態
+type gestureSegmenter struct {
+	samples   []models.IMUSample
+	active    bool
+	lastMoved int64 // しきい値を最後に超えたサンプルの時刻
+}
+
+var (
+	gestureRecognizer  = newGestureRecognizer(rules.Gesture)
+	gestureSegmenters  = map[string]*gestureSegmenter{}
+	gestureSegmenterMu sync.Mutex
+)
+
+func newGestureRecognizer(config GestureRules) GestureRecognizer {
+	if config.Recognizer == "dtw" {
+		templates, err := loadGestureTemplates(config.TemplatesFile)
+		if err == nil && len(templates) > 0 {
+			log.Printf("Loaded %d gesture templates from %s", len(templates), config.TemplatesFile)
+			return &dtwRecognizer{templates: templates, maxDistance: config.MaxDistance}
+		}
+		log.Printf("Failed to load gesture templates %s, falling back to threshold recognizer: %v", config.TemplatesFile, err)
+	}
+	return &thresholdRecognizer{config: config}
+}
+
+// ProcessIMUSamples デバイスから届いたIMUのサンプルを区切り、認識したジェスチャーを入力として処理する
+// state が空の場合はプレイヤーの現在の状態を使う
+func ProcessIMUSamples(deviceID, state string, samples []models.IMUSample) []GestureResult {
+	segments := segmentIMUSamples(deviceID, samples)
+	results := make([]GestureResult, 0, len(segments))
+	for _, segment := range segments {
+		action, confidence := gestureRecognizer.Recognize(segment)
+		results = append(results, GestureResult{Action: action, Confidence: confidence})
+	}
+
+	// 記録中は区切る前の生のサンプルを保存し、動作は入力として処理しない
+	best := GestureResult{}
+	for _, result := range results {
+		if result.Action != "" && result.Confidence >= best.Confidence {
+			best = result
+		}
+	}
+	recorded, err := recordGestureSamples(deviceID, samples, best.Action, best.Confidence)
+	if recorded {
+		for i := range results {
+			results[i].Result = "recorded"
+			if err != nil {
+				results[i].Result = err.Error()
+			}
+		}
+		return results
+	}
+
+	for i, segment := range segments {
+		result := &results[i]
+		action, confidence := result.Action, result.Confidence
+
+		if action == "" || confidence < rules.Gesture.MinConfidence {
+			result.Result = "low confidence"
+			continue
+		}
+
+		input := dto.DeviceInput{
+			DeviceID:   deviceID,
+			Action:     action,
+			State:      state,
+			Timestamp:  segment[len(segment)-1].T,
+			Confidence: &confidence,
+		}
+		if input.State == "" {
+			input.State = devicePlayerState(deviceID)
+		}
+
+		// 認識したジェスチャーも他の入力と同じくレート制限する
+		if err := AllowDeviceInput(deviceID, ""); err != nil {
+			result.Result = err.Error()
+			continue
+		}
+		err = HttpProcessInputFromDevice(input)
+		result.Result = "Input processed successfully"
+		if err != nil {
+			result.Result = err.Error()
+		}
+	}
+	return results
+}
+
+// デバイスに対応するプレイヤーの現在の状態
+func devicePlayerState(deviceID string) string {
+	mu.Lock()
+	defer mu.Unlock()
+	player, _ := getPlayersByDevice(deviceID)
+	if player == nil {
+		return ""
+	}
+	return player.State
+}
+
+// ClearIMUSamples デバイスの切断時に途中のサンプルを破棄する
+func ClearIMUSamples(deviceID string) {
+	gestureSegmenterMu.Lock()
+	defer gestureSegmenterMu.Unlock()
+	delete(gestureSegmenters, deviceID)
+}
+
+// サンプルを追加し、終了した動作のサンプルを返す
+func segmentIMUSamples(deviceID string, samples []models.IMUSample) [][]models.IMUSample {
+	gestureSegmenterMu.Lock()
+	defer gestureSegmenterMu.Unlock()
+
+	s, exists := gestureSegmenters[deviceID]
+	if !exists {
+		s = &gestureSegmenter{}
+		gestureSegmenters[deviceID] = s
+	}
+
+	config := rules.Gesture
+	var segments [][]models.IMUSample
+	for _, sample := range samples {
+		moving := math.Abs(accelMagnitude(sample)-1) > config.StartAccelG || gyroMagnitude(sample) > config.StartGyroDps
+
+		if !s.active {
+			if moving {
+				s.active = true
+				s.samples = []models.IMUSample{sample}
+				s.lastMoved = sample.T
+			}
+			continue
+		}
+
+		s.samples = append(s.samples, sample)
+		if moving {
+			s.lastMoved = sample.T
+		}
+
+		duration := sample.T - s.samples[0].T
+		if sample.T-s.lastMoved < config.QuietMs && duration < config.MaxDurationMs {
+			continue
+		}
+
+		// 静止した (または長すぎる) ので動作を区切る
+		s.active = false
+		if duration >= config.MinDurationMs {
+			segments = append(segments, s.samples)
+		}
+		s.samples = nil
+	}
+	return segments
+}
+
+func accelMagnitude(s models.IMUSample) float64 {
+	return math.Sqrt(s.AX*s.AX + s.AY*s.AY + s.AZ*s.AZ)
+}
+
+func gyroMagnitude(s models.IMUSample) float64 {
+	return math.Sqrt(s.GX*s.GX + s.GY*s.GY + s.GZ*s.GZ)
+}
+
+// しきい値による認識
+type thresholdRecognizer struct {
+	config GestureRules
+}
+
+func (r *thresholdRecognizer) Recognize(samples []models.IMUSample) (string, float64) {
+	var peakAccel, peakGyro float64
+	shakes := 0
+	prevSign := 0
+	for _, s := range samples {
+		deviation := accelMagnitude(s) - 1
+		peakAccel = math.Max(peakAccel, math.Abs(deviation))
+		peakGyro = math.Max(peakGyro, gyroMagnitude(s))
+
+		// 加速度の向きが反転した回数を振った回数とみなす
+		sign := 0
+		if deviation > r.config.StartAccelG {
+			sign = 1
+		} else if deviation < -r.config.StartAccelG {
+			sign = -1
+		}
+		if sign != 0 && prevSign != 0 && sign != prevSign {
+			shakes++
+		}
+		if sign != 0 {
+			prevSign = sign
+		}
+	}
+	duration := samples[len(samples)-1].T - samples[0].T
+
+	switch {
+	case duration >= r.config.CollectionMinMs && shakes >= r.config.CollectionMinShakes:
+		return "collection", clampConfidence(float64(shakes) / float64(2*r.config.CollectionMinShakes))
+	case peakAccel >= r.config.AttackAccelG:
+		return "attack", clampConfidence(peakAccel / (1.5 * r.config.AttackAccelG))
+	case peakGyro >= r.config.DefendGyroDps:
+		return "defend", clampConfidence(peakGyro / (1.5 * r.config.DefendGyroDps))
+	}
+	return "", 0
+}
+
+// 確からしさを 0〜1 に丸める (NaN は 0)
+func clampConfidence(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0
+	}
+	return math.Max(0, math.Min(1, v))
+}
+
+// ジェスチャーのテンプレート
+type GestureTemplate struct {
+	Action  string             `json:"action"`
+	Samples []models.IMUSample `json:"samples"`
+
+	features [][6]float64
+}
+
+func loadGestureTemplates(path string) ([]GestureTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var templates []GestureTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		templates[i].features = gestureFeatures(templates[i].Samples)
+	}
+	return templates, nil
+}
+
+// テンプレートとのDTW距離による認識
+// 最も近いテンプレートのアクションを選び、別のアクションのテンプレートとの距離の差を確からしさとする
+type dtwRecognizer struct {
+	templates   []GestureTemplate
+	maxDistance float64
+}
+
+func (r *dtwRecognizer) Recognize(samples []models.IMUSample) (string, float64) {
+	features := gestureFeatures(samples)
+
+	best := map[string]float64{} // アクション → 最小距離
+	for _, t := range r.templates {
+		d := dtwDistance(features, t.features)
+		if current, exists := best[t.Action]; !exists || d < current {
+			best[t.Action] = d
+		}
+	}
+
+	action, nearest, second := "", math.Inf(1), math.Inf(1)
+	for a, d := range best {
+		if d < nearest {
+			action, nearest, second = a, d, nearest
+		} else if d < second {
+			second = d
+		}
+	}
+	if action == "" || nearest > r.maxDistance {
+		return "", 0
+	}
+
+	if math.IsInf(second, 1) {
+		return action, clampConfidence(1 - nearest/r.maxDistance)
+	}
+	// 2つのアクションのテンプレートと完全に一致する場合はどちらとも判断できない
+	if second == 0 {
+		return action, 0
+	}
+	return action, clampConfidence((second - nearest) / second)
+}
+
+// サンプルを一定の長さにリサンプリングし、角速度を加速度と同程度の大きさにそろえる
+func gestureFeatures(samples []models.IMUSample) [][6]float64 {
+	if len(samples) == 0 {
+		return nil
+	}
+	features := make([][6]float64, gestureResampleLength)
+	for i := range features {
+		s := samples[i*(len(samples)-1)/(gestureResampleLength-1)]
+		features[i] = [6]float64{s.AX, s.AY, s.AZ, s.GX / 100, s.GY / 100, s.GZ / 100}
+	}
+	return features
+}
+
+// 経路の長さで正規化したDTW距離
+func dtwDistance(a, b [][6]float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return math.Inf(1)
+	}
+
+	cost := make([][]float64, len(a)+1)
+	for i := range cost {
+		cost[i] = make([]float64, len(b)+1)
+		for j := range cost[i] {
+			cost[i][j] = math.Inf(1)
+		}
+	}
+	cost[0][0] = 0
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			var d float64
+			for k := range a[i-1] {
+				diff := a[i-1][k] - b[j-1][k]
+				d += diff * diff
+			}
+			cost[i][j] = math.Sqrt(d) + math.Min(cost[i-1][j-1], math.Min(cost[i-1][j], cost[i][j-1]))
+		}
+	}
+	return cost[len(a)][len(b)] / float64(len(a)+len(b))
+}
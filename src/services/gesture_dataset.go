@@ -0,0 +1,237 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"md2s/models"
+	"md2s/repositorys"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 記録中の生のサンプルを1つのサンプルとして保存する長さ
+const gestureRecordingWindowMs = 2000
+
+var (
+	ErrInvalidLabel          = errors.New("label is required")
+	ErrInvalidUser           = errors.New("invalid user ID")
+	ErrGestureSampleNotFound = errors.New("gesture sample not found")
+	ErrUnknownExportFormat   = errors.New("format must be csv or jsonl")
+)
+
+// GestureRecording デバイスのジェスチャーの記録
+// 記録中のデバイスの動作は入力として処理せず、ラベルを付けて保存する
+// 区切りのしきい値の調整に使えるように、動作を区切る前の生のサンプルを一定の長さごとに保存する
+type GestureRecording struct {
+	DeviceID  string       `json:"deviceId"`
+	Label     string       `json:"label"`
+	UserID    *models.UUID `json:"userId"`
+	StartedAt time.Time    `json:"startedAt"`
+	Recorded  int          `json:"recorded"` // 保存したサンプル数
+
+	// まだ保存していない生のサンプルと、その間に認識した動作
+	pending    []models.IMUSample
+	action     string
+	confidence float64
+}
+
+var (
+	gestureRecordings   = map[string]*GestureRecording{} // デバイスID → 記録
+	gestureRecordingsMu sync.Mutex
+)
+
+// StartGestureRecording デバイスのジェスチャーの記録を開始 (記録中の場合はラベルを変更する)
+func StartGestureRecording(deviceID, label, userID string) (*GestureRecording, error) {
+	if label == "" {
+		return nil, ErrInvalidLabel
+	}
+	recording := &GestureRecording{DeviceID: deviceID, Label: label, StartedAt: time.Now()}
+	if userID != "" {
+		id, err := models.StringToUUID(userID)
+		if err != nil {
+			return nil, ErrInvalidUser
+		}
+		recording.UserID = &id
+	}
+
+	gestureRecordingsMu.Lock()
+	previous := gestureRecordings[deviceID]
+	gestureRecordings[deviceID] = recording
+	gestureRecordingsMu.Unlock()
+	log.Printf("Recording gestures of device %s as %s", deviceID, label)
+
+	// 以前のラベルで記録していたサンプルを保存する
+	if previous != nil {
+		flushGestureRecording(previous)
+	}
+	return recording, nil
+}
+
+// StopGestureRecording デバイスのジェスチャーの記録を終了 (まだ保存していないサンプルを保存する)
+func StopGestureRecording(deviceID string) (*GestureRecording, bool) {
+	gestureRecordingsMu.Lock()
+	recording, exists := gestureRecordings[deviceID]
+	delete(gestureRecordings, deviceID)
+	gestureRecordingsMu.Unlock()
+
+	if !exists {
+		return nil, false
+	}
+	flushGestureRecording(recording)
+
+	gestureRecordingsMu.Lock()
+	defer gestureRecordingsMu.Unlock()
+	r := *recording
+	return &r, true
+}
+
+// ListGestureRecordings 記録中のデバイスの一覧を取得
+func ListGestureRecordings() []GestureRecording {
+	gestureRecordingsMu.Lock()
+	defer gestureRecordingsMu.Unlock()
+
+	recordings := make([]GestureRecording, 0, len(gestureRecordings))
+	for _, r := range gestureRecordings {
+		recording := *r
+		recording.pending = nil
+		recordings = append(recordings, recording)
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].DeviceID < recordings[j].DeviceID
+	})
+	return recordings
+}
+
+// 記録中であれば生のサンプルを追加し、一定の長さになったら保存する (記録中でなければ false)
+// action, confidence はこのサンプルの間に認識した動作 (認識の精度の確認用)
+func recordGestureSamples(deviceID string, samples []models.IMUSample, action string, confidence float64) (bool, error) {
+	gestureRecordingsMu.Lock()
+	recording, exists := gestureRecordings[deviceID]
+	if !exists {
+		gestureRecordingsMu.Unlock()
+		return false, nil
+	}
+	recording.pending = append(recording.pending, samples...)
+	if action != "" && confidence >= recording.confidence {
+		recording.action, recording.confidence = action, confidence
+	}
+	pending := recording.pending
+	full := len(pending) > 0 && pending[len(pending)-1].T-pending[0].T >= gestureRecordingWindowMs
+	gestureRecordingsMu.Unlock()
+
+	if !full {
+		return true, nil
+	}
+	return true, flushGestureRecording(recording)
+}
+
+// まだ保存していないサンプルを保存する (保存できた場合だけ記録したサンプル数に数える)
+func flushGestureRecording(recording *GestureRecording) error {
+	gestureRecordingsMu.Lock()
+	samples := recording.pending
+	sample := models.GestureSample{
+		DeviceID:             recording.DeviceID,
+		UserID:               recording.UserID,
+		Label:                recording.Label,
+		Samples:              samples,
+		RecognizedAction:     recording.action,
+		RecognizedConfidence: recording.confidence,
+	}
+	recording.pending = nil
+	recording.action, recording.confidence = "", 0
+	gestureRecordingsMu.Unlock()
+
+	if len(samples) == 0 {
+		return nil
+	}
+	sample.DurationMs = samples[len(samples)-1].T - samples[0].T
+	if err := repositorys.CreateGestureSample(&sample); err != nil {
+		log.Printf("Failed to save gesture sample from device %s: %v", recording.DeviceID, err)
+		return err
+	}
+
+	gestureRecordingsMu.Lock()
+	defer gestureRecordingsMu.Unlock()
+	recording.Recorded++
+	return nil
+}
+
+// ListGestureSamples ジェスチャーのサンプルを取得 (label, deviceID が空の場合は絞り込まない)
+func ListGestureSamples(label, deviceID string) ([]models.GestureSample, error) {
+	return repositorys.GetGestureSamples(label, deviceID)
+}
+
+// LabelGestureSample サンプルのラベルを変更
+func LabelGestureSample(sampleID int, label string) error {
+	if label == "" {
+		return ErrInvalidLabel
+	}
+	found, err := repositorys.UpdateGestureSampleLabel(sampleID, label)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrGestureSampleNotFound
+	}
+	return nil
+}
+
+// DeleteGestureSample サンプルを削除
+func DeleteGestureSample(sampleID int) error {
+	found, err := repositorys.DeleteGestureSample(sampleID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrGestureSampleNotFound
+	}
+	return nil
+}
+
+// WriteGestureSamples サンプルを書き出す
+//
+//	csv:   IMUのサンプル1つにつき1行 (sample_id, label, device_id, user_id, t, ax, ay, az, gx, gy, gz)
+//	jsonl: ジェスチャーのサンプル1つにつき1行
+func WriteGestureSamples(w io.Writer, format string, samples []models.GestureSample) error {
+	if format != "csv" && format != "jsonl" {
+		return ErrUnknownExportFormat
+	}
+
+	if format == "jsonl" {
+		encoder := json.NewEncoder(w)
+		for _, sample := range samples {
+			if err := encoder.Encode(sample); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"sample_id", "label", "device_id", "user_id", "t", "ax", "ay", "az", "gx", "gy", "gz"})
+	for _, sample := range samples {
+		userID := ""
+		if sample.UserID != nil {
+			userID = sample.UserID.String()
+		}
+		for _, s := range sample.Samples {
+			writer.Write([]string{
+				strconv.Itoa(sample.ID), sample.Label, sample.DeviceID, userID,
+				strconv.FormatInt(s.T, 10),
+				formatFloat(s.AX), formatFloat(s.AY), formatFloat(s.AZ),
+				formatFloat(s.GX), formatFloat(s.GY), formatFloat(s.GZ),
+			})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
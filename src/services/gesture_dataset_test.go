@@ -0,0 +1,120 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"md2s/models"
+	"md2s/repositorys"
+	"strings"
+	"testing"
+)
+
+func TestStartGestureRecording(t *testing.T) {
+	tests := []struct {
+		name   string
+		label  string
+		userID string
+		want   error
+	}{
+		{"ラベルなし", "", "", ErrInvalidLabel},
+		{"不正なユーザーID", "attack", "not-a-uuid", ErrInvalidUser},
+		{"ラベルのみ", "attack", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const deviceID = "test-recording-start"
+			defer StopGestureRecording(deviceID)
+
+			if _, err := StartGestureRecording(deviceID, tt.label, tt.userID); err != tt.want {
+				t.Errorf("StartGestureRecording() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordGestureSamples(t *testing.T) {
+	const deviceID = "test-recording-device"
+
+	if recorded, err := recordGestureSamples(deviceID, imuSamples(0, 100, restSample), "", 0); recorded || err != nil {
+		t.Fatalf("recordGestureSamples() before recording = %v, %v, want false", recorded, err)
+	}
+
+	if _, err := StartGestureRecording(deviceID, "attack", ""); err != nil {
+		t.Fatal(err)
+	}
+	defer StopGestureRecording(deviceID)
+
+	// 一定の長さになるまでは保存せずに溜めておき、最も確からしい動作を覚えておく
+	recordGestureSamples(deviceID, imuSamples(0, 1000, punchSample), "attack", 0.9)
+	recordGestureSamples(deviceID, imuSamples(1000, 1500, twistSample), "defend", 0.7)
+
+	gestureRecordingsMu.Lock()
+	recording := gestureRecordings[deviceID]
+	pending, action := len(recording.pending), recording.action
+	gestureRecordingsMu.Unlock()
+	if pending != 150 || action != "attack" {
+		t.Fatalf("pending = %d samples as %q, want 150 as attack", pending, action)
+	}
+
+	// 一定の長さになったら保存する (データベースがない場合は保存できず、数えない)
+	recorded, err := recordGestureSamples(deviceID, imuSamples(1500, 2100, restSample), "", 0)
+	if !recorded || err != repositorys.ErrNoDatabase {
+		t.Fatalf("recordGestureSamples() = %v, %v, want true, %v", recorded, err, repositorys.ErrNoDatabase)
+	}
+
+	gestureRecordingsMu.Lock()
+	pending, count := len(recording.pending), recording.Recorded
+	gestureRecordingsMu.Unlock()
+	if pending != 0 || count != 0 {
+		t.Errorf("after flush: pending = %d, recorded = %d, want 0, 0", pending, count)
+	}
+}
+
+func TestWriteGestureSamples(t *testing.T) {
+	userID, err := models.StringToUUID("123e4567-e89b-12d3-a456-426614174000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := []models.GestureSample{
+		{ID: 1, Label: "attack", DeviceID: "d1", UserID: &userID, Samples: []models.IMUSample{{T: 10, AZ: 2.5}, {T: 20, AZ: 1}}},
+		{ID: 2, Label: "defend", DeviceID: "d2", Samples: []models.IMUSample{{T: 30, GX: 300}}},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteGestureSamples(&buf, "csv", samples); err != nil {
+			t.Fatal(err)
+		}
+		want := strings.Join([]string{
+			"sample_id,label,device_id,user_id,t,ax,ay,az,gx,gy,gz",
+			"1,attack,d1,123e4567-e89b-12d3-a456-426614174000,10,0,0,2.5,0,0,0",
+			"1,attack,d1,123e4567-e89b-12d3-a456-426614174000,20,0,0,1,0,0,0",
+			"2,defend,d2,,30,0,0,0,300,0,0",
+		}, "\n") + "\n"
+		if buf.String() != want {
+			t.Errorf("csv =\n%s\nwant\n%s", buf.String(), want)
+		}
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteGestureSamples(&buf, "jsonl", samples); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != len(samples) {
+			t.Fatalf("jsonl has %d lines, want %d", len(lines), len(samples))
+		}
+		var decoded models.GestureSample
+		if err := json.Unmarshal([]byte(lines[1]), &decoded); err != nil || decoded.Label != "defend" || len(decoded.Samples) != 1 {
+			t.Errorf("line 2 = %s (%v), want the defend sample", lines[1], err)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		if err := WriteGestureSamples(&bytes.Buffer{}, "xml", samples); err != ErrUnknownExportFormat {
+			t.Errorf("WriteGestureSamples(xml) = %v, want %v", err, ErrUnknownExportFormat)
+		}
+	})
+}
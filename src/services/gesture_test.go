@@ -0,0 +1,145 @@
+package services
+
+import (
+	"encoding/json"
+	"md2s/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 10msごとのサンプルを作る (f は経過時間からサンプルを作る)
+func imuSamples(from, to int64, f func(t int64) models.IMUSample) []models.IMUSample {
+	var samples []models.IMUSample
+	for t := from; t < to; t += 10 {
+		s := f(t)
+		s.T = t
+		samples = append(samples, s)
+	}
+	return samples
+}
+
+// 静止 (重力のみ)
+func restSample(int64) models.IMUSample { return models.IMUSample{AZ: 1} }
+
+// 突き (前方向に強い加速度)
+func punchSample(int64) models.IMUSample { return models.IMUSample{AZ: 3} }
+
+// ひねり (強い角速度)
+func twistSample(int64) models.IMUSample { return models.IMUSample{AZ: 1, GX: 400} }
+
+// 振る (50msごとに加速度の向きが反転する)
+func shakeSample(t int64) models.IMUSample {
+	if t/50%2 == 0 {
+		return models.IMUSample{AZ: 2}
+	}
+	return models.IMUSample{AZ: 0}
+}
+
+func TestSegmentIMUSamples(t *testing.T) {
+	punch := append(imuSamples(0, 150, punchSample), imuSamples(150, 400, restSample)...)
+
+	tests := []struct {
+		name    string
+		batches [][]models.IMUSample
+		want    []int // 区切った動作ごとのサンプル数 (静止してから QuietMs までのサンプルを含む)
+	}{
+		{"静止していれば区切らない", [][]models.IMUSample{imuSamples(0, 500, restSample)}, nil},
+		{"動いてから静止したら区切る", [][]models.IMUSample{punch}, []int{23}},
+		{"複数回に分かれて届いても1つの動作", [][]models.IMUSample{punch[:10], punch[10:]}, []int{23}},
+		{"長すぎる動作は上限で区切る", [][]models.IMUSample{imuSamples(0, 2000, punchSample)}, []int{151}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const deviceID = "test-segment-device"
+			defer ClearIMUSamples(deviceID)
+
+			var got []int
+			for _, batch := range tt.batches {
+				for _, segment := range segmentIMUSamples(deviceID, batch) {
+					got = append(got, len(segment))
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("segments = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("segments = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestThresholdRecognizer(t *testing.T) {
+	recognizer := &thresholdRecognizer{config: defaultRules.Gesture}
+
+	tests := []struct {
+		name    string
+		samples []models.IMUSample
+		want    string
+	}{
+		{"突きは攻撃", imuSamples(0, 150, punchSample), "attack"},
+		{"ひねりは防御", imuSamples(0, 150, twistSample), "defend"},
+		{"振り続けると溜め", imuSamples(0, 700, shakeSample), "collection"},
+		{"短く振っただけでは溜めない", imuSamples(0, 200, shakeSample), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, confidence := recognizer.Recognize(tt.samples)
+			if action != tt.want {
+				t.Fatalf("Recognize() = %q, want %q", action, tt.want)
+			}
+			if action != "" && (confidence <= 0 || confidence > 1) {
+				t.Errorf("confidence = %v, want (0, 1]", confidence)
+			}
+		})
+	}
+}
+
+func TestDTWRecognizer(t *testing.T) {
+	templates := []GestureTemplate{
+		{Action: "attack", Samples: imuSamples(0, 150, punchSample)},
+		{Action: "defend", Samples: imuSamples(0, 150, twistSample)},
+	}
+	data, err := json.Marshal(templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "templates.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := defaultRules.Gesture
+	config.Recognizer = "dtw"
+	config.TemplatesFile = path
+	recognizer, ok := newGestureRecognizer(config).(*dtwRecognizer)
+	if !ok {
+		t.Fatal("templates were not loaded")
+	}
+
+	// 速さの違う突き (サンプル数が違っても同じ動作として比べる)
+	slowPunch := imuSamples(0, 300, func(int64) models.IMUSample { return models.IMUSample{AZ: 2.8} })
+	if action, confidence := recognizer.Recognize(slowPunch); action != "attack" || confidence < 0.5 {
+		t.Errorf("Recognize(slow punch) = %q, %v, want attack with confidence >= 0.5", action, confidence)
+	}
+
+	// どのテンプレートからも遠い動作は認識しない
+	spin := imuSamples(0, 150, func(int64) models.IMUSample { return models.IMUSample{AX: 4, GX: 2000, GY: 2000} })
+	if action, _ := recognizer.Recognize(spin); action != "" {
+		t.Errorf("Recognize(spin) = %q, want no action", action)
+	}
+}
+
+func TestNewGestureRecognizerFallback(t *testing.T) {
+	config := defaultRules.Gesture
+	config.Recognizer = "dtw"
+	config.TemplatesFile = filepath.Join(t.TempDir(), "missing.json")
+	if _, ok := newGestureRecognizer(config).(*thresholdRecognizer); !ok {
+		t.Error("missing templates did not fall back to the threshold recognizer")
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// ガードブレイク: 一定時間行動できない状態にして、時間が経ったらDFを一部回復して戦闘に戻す
+func breakGuard(player *Player) {
+	interruptCharge(player)
+
+	player.State = "guardBroken"
+	stunUntil := time.Now().Add(time.Duration(rules.GuardBreak.StunMs) * time.Millisecond)
+	player.stunUntil = stunUntil
+	log.Printf("Player %s's guard was broken", player.ID)
+	emitGameEvent("guard_break", player, nil, int(rules.GuardBreak.StunMs))
+
+	time.AfterFunc(time.Until(stunUntil), func() {
+		mu.Lock()
+		defer mu.Unlock()
+		recoverGuard(player, stunUntil)
+	})
+}
+
+// ガードブレイクから回復 (ゲームが終わった場合や別のガードブレイクが始まっている場合は何もしない)
+func recoverGuard(player *Player, stunUntil time.Time) {
+	if player.State != "guardBroken" || !player.stunUntil.Equal(stunUntil) || GameOver {
+		return
+	}
+
+	player.State = "fighting"
+	player.DF = max(player.DF, min(rules.GuardBreak.RecoveryDF, player.class.DF))
+	log.Printf("Player %s recovered from guard break", player.ID)
+	emitGameEvent("guard_recover", player, nil, player.DF)
+	updateGameState()
+}
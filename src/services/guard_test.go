@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProcessDefenseGuardBreak(t *testing.T) {
+	cost := defaultClasses[defaultClass].DefendCost
+
+	tests := []struct {
+		name      string
+		df        int
+		wantDF    int
+		wantState string
+	}{
+		{"defend", 100, 100 - cost, "fighting"},
+		{"last defense breaks guard", cost, 0, "guardBroken"},
+		{"no DF breaks guard", 0, 0, "guardBroken"},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := newTestPlayer("player1")
+			player.DF = tt.df
+			processDefense(player)
+
+			if player.DF != tt.wantDF || player.State != tt.wantState {
+				t.Errorf("DF, state = %d, %s, want %d, %s", player.DF, player.State, tt.wantDF, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestRecoverGuard(t *testing.T) {
+	stunUntil := time.Now()
+	recoveryDF := rules.GuardBreak.RecoveryDF
+
+	tests := []struct {
+		name      string
+		state     string
+		stunUntil time.Time
+		gameOver  bool
+		wantState string
+		wantDF    int
+	}{
+		{"recovers", "guardBroken", stunUntil, false, "fighting", recoveryDF},
+		{"newer guard break", "guardBroken", stunUntil.Add(time.Second), false, "guardBroken", 0},
+		{"no longer guard broken", "respawning", stunUntil, false, "respawning", 0},
+		{"game over", "guardBroken", stunUntil, true, "guardBroken", 0},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	defer func(gameOver bool) { GameOver = gameOver }(GameOver)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := newTestPlayer("player1")
+			player.State = tt.state
+			player.DF = 0
+			player.stunUntil = tt.stunUntil
+			GameOver = tt.gameOver
+
+			recoverGuard(player, stunUntil)
+
+			if player.State != tt.wantState || player.DF != tt.wantDF {
+				t.Errorf("state, DF = %s, %d, want %s, %d", player.State, player.DF, tt.wantState, tt.wantDF)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// デバイスをプレイヤースロットにペアリングする (テストの終わりに解除する)
+func pairTestDevice(t *testing.T, deviceID, playerID string) {
+	t.Helper()
+	mu.Lock()
+	defer mu.Unlock()
+
+	deviceBindings[deviceID] = &DeviceBinding{DeviceID: deviceID, PlayerID: playerID, PairedAt: time.Now()}
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(deviceBindings, deviceID)
+	})
+}
+
+// 試合中のプレイヤー (既定のクラス)
+func newTestPlayer(id string) *Player {
+	player := &Player{ID: id, Action: "none", State: "fighting", Power: 1, Stocks: stockCount()}
+	applyClass(player, defaultClass)
+	return player
+}
+
+// 両方のスロットにプレイヤーが参加した状態にする (テストの終わりに元に戻す)
+func setupTestMatch(t *testing.T) (*Player, *Player) {
+	t.Helper()
+	mu.Lock()
+	defer mu.Unlock()
+
+	saved, savedGameOver := players, GameOver
+	players = map[string]*Player{
+		"player1": newTestPlayer("player1"),
+		"player2": newTestPlayer("player2"),
+	}
+	GameOver = false
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		resetLobby()
+		players, GameOver = saved, savedGameOver
+	})
+	return players["player1"], players["player2"]
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// デバイスごとに保持する処理済み入力の数と保持期間
+const (
+	replayWindowSize = 128
+	replayWindowTTL  = 10 * time.Minute
+)
+
+var (
+	// ErrStaleInput リプレイウィンドウより古いシーケンス番号
+	ErrStaleInput = errors.New("stale input sequence")
+	// 最初のリクエストの処理が途中で終わった (panic など) 場合に再送へ返すエラー
+	errInputNotProcessed = errors.New("input was not processed")
+)
+
+// InputKey 重複を判定する入力のキー
+// Idempotency-Key とシーケンス番号は別々に記録する (同じ "1" でも別の入力として扱う)
+type InputKey struct {
+	Idempotency string  // Idempotency-Key ヘッダー
+	Seq         *uint64 // デバイスのシーケンス番号
+	BootID      string  // シーケンス番号を数え始めたデバイスの起動ID
+}
+
+// 応答に含めるキー
+func (k InputKey) String() string {
+	if k.Idempotency != "" {
+		return k.Idempotency
+	}
+	if k.Seq != nil {
+		return strconv.FormatUint(*k.Seq, 10)
+	}
+	return ""
+}
+
+// 処理済み (または処理中) の入力
+type inputRecord struct {
+	done       chan struct{}
+	err        error
+	receivedAt time.Time
+}
+
+// 受信順に保持する処理済み入力
+type inputRecords[K comparable] struct {
+	records map[K]*inputRecord
+	order   []K
+}
+
+// デバイスごとのリプレイウィンドウ
+type replayWindow struct {
+	keys   inputRecords[string]
+	seqs   inputRecords[uint64]
+	bootID string
+	maxSeq uint64
+	hasSeq bool
+}
+
+var (
+	replayWindows   = map[string]*replayWindow{}
+	replayWindowsMu sync.Mutex
+	// 保持期間を過ぎたウィンドウを最後に削除した時刻
+	replayWindowsPrunedAt time.Time
+)
+
+// ProcessDeviceInputOnce 同じキーの入力を一度だけ処理する
+// キーが重複した場合は処理せずに最初の結果を返し、duplicate を true にする
+// キーが空の場合は重複チェックをせずにそのまま処理する
+// ペアリングされていないデバイスのウィンドウは作らない (ErrInvalidDevice を返す)
+func ProcessDeviceInputOnce(deviceID string, key InputKey, process func() error) (duplicate bool, err error) {
+	if key.Idempotency == "" && key.Seq == nil {
+		return false, process()
+	}
+	if !DevicePaired(deviceID) {
+		return false, ErrInvalidDevice
+	}
+
+	replayWindowsMu.Lock()
+	now := time.Now()
+	pruneReplayWindows(now)
+	w, exists := replayWindows[deviceID]
+	if !exists {
+		w = &replayWindow{}
+		replayWindows[deviceID] = w
+	}
+	w.keys.expire(now)
+	if w.seqs.expire(now) {
+		w.hasSeq = false
+		w.maxSeq = 0
+	}
+
+	var record *inputRecord
+	if key.Idempotency != "" {
+		record = w.keys.get(key.Idempotency)
+	} else {
+		if !w.acceptSequence(*key.Seq, key.BootID) {
+			replayWindowsMu.Unlock()
+			return false, ErrStaleInput
+		}
+		record = w.seqs.get(*key.Seq)
+	}
+	if record != nil {
+		replayWindowsMu.Unlock()
+		// 最初のリクエストがまだ処理中なら終わるまで待つ
+		<-record.done
+		return true, record.err
+	}
+
+	record = &inputRecord{done: make(chan struct{}), err: errInputNotProcessed, receivedAt: now}
+	if key.Idempotency != "" {
+		w.keys.add(key.Idempotency, record)
+	} else {
+		w.seqs.add(*key.Seq, record)
+	}
+	replayWindowsMu.Unlock()
+
+	// process が panic しても、待っている再送が止まらないようにする
+	defer close(record.done)
+	record.err = process()
+	return false, record.err
+}
+
+// 全ての記録が保持期間を過ぎたデバイスのウィンドウを削除する (replayWindowsMu を持って呼ぶ)
+func pruneReplayWindows(now time.Time) {
+	if now.Sub(replayWindowsPrunedAt) < replayWindowTTL {
+		return
+	}
+	replayWindowsPrunedAt = now
+	for deviceID, w := range replayWindows {
+		if w.keys.expire(now) && w.seqs.expire(now) {
+			delete(replayWindows, deviceID)
+		}
+	}
+}
+
+// ウィンドウより古いシーケンス番号は受け付けない
+// 起動IDが変わった場合は、デバイスが再起動してシーケンス番号を数え直したものとしてウィンドウをリセットする
+// 起動IDを送らないデバイスは、ウィンドウより大きくシーケンス番号が戻った場合に再起動したものとみなす
+func (w *replayWindow) acceptSequence(seq uint64, bootID string) bool {
+	if w.hasSeq && bootID != w.bootID {
+		w.resetSequence()
+	}
+	if w.hasSeq && seq+replayWindowSize <= w.maxSeq {
+		if bootID != "" {
+			return false
+		}
+		w.resetSequence()
+	}
+	w.bootID = bootID
+	if !w.hasSeq || seq > w.maxSeq {
+		w.maxSeq = seq
+		w.hasSeq = true
+	}
+	return true
+}
+
+func (w *replayWindow) resetSequence() {
+	w.seqs = inputRecords[uint64]{}
+	w.hasSeq = false
+	w.maxSeq = 0
+}
+
+func (r *inputRecords[K]) get(key K) *inputRecord {
+	return r.records[key]
+}
+
+func (r *inputRecords[K]) add(key K, record *inputRecord) {
+	if r.records == nil {
+		r.records = map[K]*inputRecord{}
+	}
+	r.records[key] = record
+	r.order = append(r.order, key)
+	if len(r.order) > replayWindowSize {
+		delete(r.records, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// 保持期間を過ぎた記録を削除 (全て削除された場合は true)
+func (r *inputRecords[K]) expire(now time.Time) bool {
+	for len(r.order) > 0 {
+		record := r.records[r.order[0]]
+		if now.Sub(record.receivedAt) < replayWindowTTL {
+			return false
+		}
+		delete(r.records, r.order[0])
+		r.order = r.order[1:]
+	}
+	return true
+}
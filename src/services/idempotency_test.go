@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// テストの終わりにデバイスの再送の記録を消す (-count で繰り返しても同じ結果にする)
+func forgetTestReplayWindow(t *testing.T, deviceID string) {
+	t.Cleanup(func() {
+		replayWindowsMu.Lock()
+		defer replayWindowsMu.Unlock()
+		delete(replayWindows, deviceID)
+	})
+}
+
+func TestProcessDeviceInputOnce(t *testing.T) {
+	seq := func(n uint64) *uint64 { return &n }
+
+	type step struct {
+		key           InputKey
+		wantDuplicate bool
+		wantErr       error
+		wantProcessed bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"no key is always processed", []step{
+			{InputKey{}, false, nil, true},
+			{InputKey{}, false, nil, true},
+		}},
+		{"duplicate idempotency key", []step{
+			{InputKey{Idempotency: "a"}, false, nil, true},
+			{InputKey{Idempotency: "a"}, true, nil, false},
+			{InputKey{Idempotency: "b"}, false, nil, true},
+		}},
+		{"duplicate sequence", []step{
+			{InputKey{Seq: seq(1)}, false, nil, true},
+			{InputKey{Seq: seq(2)}, false, nil, true},
+			{InputKey{Seq: seq(1)}, true, nil, false},
+		}},
+		{"idempotency key and sequence are separate", []step{
+			{InputKey{Idempotency: "1"}, false, nil, true},
+			{InputKey{Seq: seq(1)}, false, nil, true},
+		}},
+		{"stale sequence with boot ID", []step{
+			{InputKey{Seq: seq(500), BootID: "x"}, false, nil, true},
+			{InputKey{Seq: seq(500 - replayWindowSize), BootID: "x"}, false, ErrStaleInput, false},
+			{InputKey{Seq: seq(500 - replayWindowSize + 1), BootID: "x"}, false, nil, true},
+		}},
+		{"new boot ID resets the window", []step{
+			{InputKey{Seq: seq(500), BootID: "x"}, false, nil, true},
+			{InputKey{Seq: seq(1), BootID: "y"}, false, nil, true},
+			{InputKey{Seq: seq(500), BootID: "y"}, false, nil, true},
+		}},
+		{"large drop without boot ID resets the window", []step{
+			{InputKey{Seq: seq(500)}, false, nil, true},
+			{InputKey{Seq: seq(1)}, false, nil, true},
+			{InputKey{Seq: seq(1)}, true, nil, false},
+		}},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceID := fmt.Sprintf("test-idempotency-%d", i)
+			pairTestDevice(t, deviceID, "player1")
+			forgetTestReplayWindow(t, deviceID)
+			for j, s := range tt.steps {
+				processed := false
+				duplicate, err := ProcessDeviceInputOnce(deviceID, s.key, func() error {
+					processed = true
+					return nil
+				})
+				if duplicate != s.wantDuplicate || !errors.Is(err, s.wantErr) || processed != s.wantProcessed {
+					t.Errorf("step %d: duplicate = %v, err = %v, processed = %v, want %v, %v, %v",
+						j, duplicate, err, processed, s.wantDuplicate, s.wantErr, s.wantProcessed)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessDeviceInputOnceReplaysError(t *testing.T) {
+	processErr := errors.New("process failed")
+	key := InputKey{Idempotency: "failed"}
+
+	pairTestDevice(t, "test-idempotency-error", "player1")
+	forgetTestReplayWindow(t, "test-idempotency-error")
+	if _, err := ProcessDeviceInputOnce("test-idempotency-error", key, func() error { return processErr }); err != processErr {
+		t.Fatalf("first err = %v, want %v", err, processErr)
+	}
+	duplicate, err := ProcessDeviceInputOnce("test-idempotency-error", key, func() error { return nil })
+	if !duplicate || err != processErr {
+		t.Errorf("replay = %v, %v, want true, %v", duplicate, err, processErr)
+	}
+}
+
+func TestProcessDeviceInputOnceUnpairedDevice(t *testing.T) {
+	const deviceID = "test-idempotency-unpaired"
+
+	processed := false
+	_, err := ProcessDeviceInputOnce(deviceID, InputKey{Idempotency: "a"}, func() error {
+		processed = true
+		return nil
+	})
+	if err != ErrInvalidDevice || processed {
+		t.Errorf("err, processed = %v, %v, want %v, false", err, processed, ErrInvalidDevice)
+	}
+
+	replayWindowsMu.Lock()
+	defer replayWindowsMu.Unlock()
+	if _, exists := replayWindows[deviceID]; exists {
+		t.Errorf("replay window was created for an unpaired device")
+	}
+}
+
+func TestPruneReplayWindows(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-replayWindowTTL)
+
+	replayWindowsMu.Lock()
+	defer replayWindowsMu.Unlock()
+	defer func(prunedAt time.Time) { replayWindowsPrunedAt = prunedAt }(replayWindowsPrunedAt)
+
+	old, recent := &replayWindow{}, &replayWindow{}
+	old.keys.add("a", &inputRecord{done: make(chan struct{}), receivedAt: expired})
+	recent.keys.add("a", &inputRecord{done: make(chan struct{}), receivedAt: now})
+	replayWindows["test-prune-old"] = old
+	replayWindows["test-prune-recent"] = recent
+	defer delete(replayWindows, "test-prune-recent")
+	replayWindowsPrunedAt = expired
+
+	pruneReplayWindows(now)
+
+	if _, exists := replayWindows["test-prune-old"]; exists {
+		t.Errorf("expired window was not pruned")
+	}
+	if _, exists := replayWindows["test-prune-recent"]; !exists {
+		t.Errorf("recent window was pruned")
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"md2s/models"
+	"md2s/repositorys"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidInputProfile  = errors.New("name and device type are required")
+	ErrInputProfileNotFound = errors.New("input profile not found")
+)
+
+// 入力プロファイルで割り当てられるゲームのアクション
+var gameActions = map[string]bool{
+	"none":       true,
+	"attack":     true,
+	"defend":     true,
+	"collection": true,
+	// 溜め攻撃 (ボタンを押した時と離した時)
+	"charge_start":   true,
+	"charge_release": true,
+	// クラス固有の技
+	"signature": true,
+	// 出現中のパワーアップを取得
+	"claim": true,
+	// 属性の切り替え
+	"switch_element": true,
+}
+
+var (
+	deviceInputMappings = map[string]models.InputMappings{} // デバイスID → 入力の対応表 (起動時とプロファイルの変更時に読み込む)
+	inputMappingsMu     sync.Mutex
+
+	// 対応表の読み込み (テストで差し替える)
+	fetchDeviceInputMappings = repositorys.GetDeviceInputMappings
+)
+
+// LoadInputMappings デバイスの入力の対応表を読み込む (起動時に呼ぶ)
+// 読み込めなかった場合は、前回読み込んだ対応表を使い続ける
+func LoadInputMappings() error {
+	// 入力の処理を止めないように、読み込みはロックの外で行う
+	mappings, err := fetchDeviceInputMappings()
+	if err != nil {
+		log.Printf("Failed to load device input profiles: %v", err)
+		return err
+	}
+
+	inputMappingsMu.Lock()
+	defer inputMappingsMu.Unlock()
+	deviceInputMappings = mappings
+	return nil
+}
+
+// デバイスの入力をゲームのアクションに変換する
+// raw (ボタンID・キーコードなど) か action (ジェスチャー名など) がプロファイルにあれば変換し、なければ action をそのまま使う
+// 読み込み済みの対応表だけを使う (入力のたびにデータベースにアクセスしない)
+func mapInputAction(deviceID, raw, action string) string {
+	inputMappingsMu.Lock()
+	defer inputMappingsMu.Unlock()
+
+	mappings := deviceInputMappings[deviceID]
+	if mapped, ok := mappings[raw]; ok && raw != "" {
+		return mapped
+	}
+	if mapped, ok := mappings[action]; ok {
+		return mapped
+	}
+	return action
+}
+
+// ListInputProfiles 入力プロファイルの一覧を取得
+func ListInputProfiles() ([]models.InputProfile, error) {
+	return repositorys.GetInputProfiles()
+}
+
+// SaveInputProfile 入力プロファイルを作成・更新 (ID が 0 の場合は作成)
+func SaveInputProfile(profile *models.InputProfile) error {
+	if profile.Name == "" || profile.DeviceType == "" {
+		return ErrInvalidInputProfile
+	}
+	for raw, action := range profile.Mappings {
+		if !gameActions[action] {
+			return fmt.Errorf("%w: %s → %s", ErrUnknownAction, raw, action)
+		}
+	}
+
+	err := repositorys.SaveInputProfile(profile)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInputProfileNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// プロファイルの変更をデバイスの対応表に反映する
+	LoadInputMappings()
+	return nil
+}
+
+// DeleteInputProfile 入力プロファイルを削除 (割り当てられていたデバイスは未設定になる)
+func DeleteInputProfile(profileID int) error {
+	found, err := repositorys.DeleteInputProfile(profileID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrInputProfileNotFound
+	}
+	// プロファイルの変更をデバイスの対応表に反映する
+	LoadInputMappings()
+	return nil
+}
+
+// AssignInputProfile デバイスに入力プロファイルを割り当てる (profileID が nil の場合は解除)
+func AssignInputProfile(deviceID string, profileID *int) error {
+	if profileID != nil {
+		if _, err := repositorys.GetInputProfile(*profileID); errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInputProfileNotFound
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if err := repositorys.UpsertDeviceColumns(deviceID, map[string]interface{}{"input_profile_id": profileID}); err != nil {
+		return err
+	}
+	// プロファイルの変更をデバイスの対応表に反映する
+	LoadInputMappings()
+	return nil
+}
+
+// ペアリングしたデバイスにデバイスの種類の既定のプロファイルを割り当てる
+// 既定のプロファイルがない場合は以前の割り当てを残す
+func assignDefaultInputProfile(deviceID string) {
+	device, err := repositorys.GetDevice(deviceID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to load device %s: %v", deviceID, err)
+		}
+		return
+	}
+
+	profile, err := repositorys.GetDefaultInputProfile(device.Type)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Failed to load input profile for device type %s: %v", device.Type, err)
+		}
+		return
+	}
+
+	if err := AssignInputProfile(deviceID, &profile.ID); err != nil {
+		log.Printf("Failed to assign input profile %s to device %s: %v", profile.Name, deviceID, err)
+		return
+	}
+	log.Printf("Input profile %s assigned to device %s", profile.Name, deviceID)
+}
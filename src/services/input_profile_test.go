@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"md2s/dto"
+	"md2s/models"
+	"testing"
+)
+
+// 入力プロファイルを作ってデバイスに割り当てる (テストの終わりに削除する)
+func assignTestInputProfile(t *testing.T, deviceID string, mappings models.InputMappings) {
+	t.Helper()
+	profile := &models.InputProfile{Name: "test-" + deviceID, DeviceType: "glove", Mappings: mappings}
+	if err := SaveInputProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		DeleteInputProfile(profile.ID)
+		DeleteDevice(deviceID)
+	})
+	if err := AssignInputProfile(deviceID, &profile.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveInputProfileValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile models.InputProfile
+		want    error
+	}{
+		{"名前なし", models.InputProfile{DeviceType: "glove"}, ErrInvalidInputProfile},
+		{"種類なし", models.InputProfile{Name: "test-invalid"}, ErrInvalidInputProfile},
+		{"ゲームにないアクション", models.InputProfile{Name: "test-invalid", DeviceType: "glove", Mappings: models.InputMappings{"b1": "jump"}}, ErrUnknownAction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SaveInputProfile(&tt.profile); !errors.Is(err, tt.want) {
+				t.Errorf("SaveInputProfile() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapInputAction(t *testing.T) {
+	assignTestInputProfile(t, "test-mapped-device", models.InputMappings{"b1": "attack", "punch": "defend"})
+
+	tests := []struct {
+		name        string
+		deviceID    string
+		raw, action string
+		want        string
+	}{
+		{"ボタンIDを変換", "test-mapped-device", "b1", "", "attack"},
+		{"ジェスチャー名を変換", "test-mapped-device", "", "punch", "defend"},
+		{"ボタンIDを優先", "test-mapped-device", "b1", "punch", "attack"},
+		{"対応表にない入力はそのまま", "test-mapped-device", "b9", "collection", "collection"},
+		{"プロファイルのないデバイス", "test-unmapped-device", "b1", "punch", "punch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mapInputAction(tt.deviceID, tt.raw, tt.action); got != tt.want {
+				t.Errorf("mapInputAction(%q, %q) = %q, want %q", tt.raw, tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadInputMappingsKeepsLastGood(t *testing.T) {
+	assignTestInputProfile(t, "test-mapped-keep", models.InputMappings{"b1": "attack"})
+
+	saved := fetchDeviceInputMappings
+	fetchDeviceInputMappings = func() (map[string]models.InputMappings, error) {
+		return nil, errors.New("database is down")
+	}
+	defer func() { fetchDeviceInputMappings = saved }()
+
+	if err := LoadInputMappings(); err == nil {
+		t.Fatal("LoadInputMappings() succeeded with a failing repository")
+	}
+	if got := mapInputAction("test-mapped-keep", "b1", ""); got != "attack" {
+		t.Errorf("mapInputAction() after a failed load = %q, want attack", got)
+	}
+}
+
+func TestInputMappingOutsideGameLock(t *testing.T) {
+	pairTestDevice(t, "test-mapped-input", "player1")
+
+	// 対応表を読み込むたびに、ゲームの状態のロックを持っていないかを記録する
+	calls, locked := 0, 0
+	saved := fetchDeviceInputMappings
+	fetchDeviceInputMappings = func() (map[string]models.InputMappings, error) {
+		calls++
+		if mu.TryLock() {
+			mu.Unlock()
+		} else {
+			locked++
+		}
+		return saved()
+	}
+	defer func() { fetchDeviceInputMappings = saved }()
+
+	assignTestInputProfile(t, "test-mapped-input", models.InputMappings{"b1": "attack"})
+	loads := calls
+	if loads == 0 {
+		t.Fatal("assigning a profile did not reload the mappings")
+	}
+
+	for i := 0; i < 3; i++ {
+		HttpProcessInputFromDevice(dto.DeviceInput{DeviceID: "test-mapped-input", Input: "b1", State: "fighting"})
+	}
+
+	if calls != loads {
+		t.Errorf("input processing loaded the mappings %d times, want 0", calls-loads)
+	}
+	if locked > 0 {
+		t.Errorf("mappings were loaded %d times while the game lock was held", locked)
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"log"
+	"md2s/models"
+	"time"
+)
+
+// ゲーム状態・イベントの更新を受け取るリスナー (MQTTブリッジ、gRPCなど)
+// リスナーはロック中に呼ばれるため、services の関数を呼び出したりブロックしてはいけない
+var (
+	gameStateListeners = map[int]func(models.GameState){}
+	gameEventListeners = map[int]func(models.GameEvent){}
+	nextListenerID     = 0
+)
+
+// AddGameStateListener ゲーム状態が更新されるたびに呼び出される関数を登録
+// 戻り値の関数を呼ぶと登録を解除する
+func AddGameStateListener(listener func(models.GameState)) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	nextListenerID++
+	id := nextListenerID
+	gameStateListeners[id] = listener
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(gameStateListeners, id)
+	}
+}
+
+// AddGameEventListener ゲームイベントが発生するたびに呼び出される関数を登録
+// 戻り値の関数を呼ぶと登録を解除する
+func AddGameEventListener(listener func(models.GameEvent)) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	nextListenerID++
+	id := nextListenerID
+	gameEventListeners[id] = listener
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(gameEventListeners, id)
+	}
+}
+
+// 登録されたリスナーにゲーム状態を通知
+func notifyGameStateListeners(gameState models.GameState) {
+	for _, listener := range gameStateListeners {
+		listener(gameState)
+	}
+}
+
+// イベントを発行
+func emitGameEvent(eventType string, player *Player, target *Player, value int) {
+	event := models.GameEvent{
+		Type:      eventType,
+		Value:     value,
+		Timestamp: time.Now().UnixMilli(),
+	}
+	if player != nil {
+		event.PlayerID = player.ID
+	}
+	if target != nil {
+		event.TargetID = target.ID
+	}
+
+	// プレイヤーにブロードキャスト
+	for _, p := range players {
+		if p.Conn != nil {
+			if err := writeGameMessage(p.Conn, models.GameMessage{Event: &event}); err != nil {
+				log.Printf("Error sending game event to player %s: %v", p.ID, err)
+			}
+		}
+	}
+
+	for _, listener := range gameEventListeners {
+		listener(event)
+	}
+
+	// デバイスに振動・LED・音のコマンドを送る
+	dispatchDeviceCommands(event)
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// ロビーのフェーズ
+const (
+	LobbyWaiting    = "waiting"     // プレイヤーの参加待ち
+	LobbyOpen       = "open"        // 両方のスロットが埋まり、準備確認の開始待ち
+	LobbyReadyCheck = "ready_check" // 準備確認中
+	LobbyPick       = "pick"        // クラスの選択中
+	LobbyCountdown  = "countdown"   // 試合開始のカウントダウン中
+	LobbyFighting   = "fighting"    // 試合中
+)
+
+var (
+	ErrLobbyNotFull = errors.New("lobby is not full")
+	ErrLobbyPhase   = errors.New("not allowed in current lobby phase")
+	ErrSlotEmpty    = errors.New("player slot is empty")
+)
+
+// ロビーのスロット
+type LobbySlot struct {
+	PlayerID string `json:"playerId"`
+	Filled   bool   `json:"filled"` // プレイヤーが参加しているか
+	UserID   string `json:"userId,omitempty"`
+	Class    string `json:"class,omitempty"`
+	DeviceID string `json:"deviceId,omitempty"` // ペアリングされているデバイス
+	Ready    bool   `json:"ready"`              // 準備確認で準備完了したか
+	Picked   bool   `json:"picked"`             // クラスを確定したか
+}
+
+// ロビーの状態
+type LobbyStatus struct {
+	Phase string      `json:"phase"`
+	Slots []LobbySlot `json:"slots"`
+	// 準備確認・クラス選択の期限
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+var lobbySlots = []string{"player1", "player2"}
+
+// ロビーの状態 (mu で保護する)
+var lobby = struct {
+	phase    string // 準備確認を始める前は空 (スロットから waiting か open を判断する)
+	ready    map[string]bool
+	picked   map[string]bool
+	deadline time.Time
+	round    int // フェーズが変わるたびに増やし、古いタイマーを無視する
+}{ready: map[string]bool{}, picked: map[string]bool{}}
+
+// 現在のロビーのフェーズ
+func lobbyPhase() string {
+	if lobby.phase != "" {
+		return lobby.phase
+	}
+	for _, slot := range lobbySlots {
+		if players[slot] == nil {
+			return LobbyWaiting
+		}
+	}
+	return LobbyOpen
+}
+
+// GetLobby ロビーの状態を取得
+func GetLobby() LobbyStatus {
+	mu.Lock()
+	defer mu.Unlock()
+
+	status := LobbyStatus{Phase: lobbyPhase(), Slots: make([]LobbySlot, 0, len(lobbySlots))}
+	if !lobby.deadline.IsZero() {
+		deadline := lobby.deadline
+		status.Deadline = &deadline
+	}
+	for _, slot := range lobbySlots {
+		s := LobbySlot{PlayerID: slot, Ready: lobby.ready[slot], Picked: lobby.picked[slot]}
+		if p := players[slot]; p != nil {
+			s.Filled = true
+			s.UserID = p.UserID
+			s.Class = p.Class
+		}
+		for id, b := range deviceBindings {
+			if b.PlayerID == slot {
+				s.DeviceID = id
+			}
+		}
+		status.Slots = append(status.Slots, s)
+	}
+	return status
+}
+
+// StartReadyCheck 準備確認を開始
+func StartReadyCheck() error {
+	mu.Lock()
+	defer mu.Unlock()
+	return startReadyCheck()
+}
+
+// ConfirmReady プレイヤーの準備完了を伝える (準備確認が始まっていなければ開始する)
+func ConfirmReady(playerID string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	player, err := lobbyPlayer(playerID)
+	if err != nil {
+		return err
+	}
+	return confirmReady(player)
+}
+
+// CancelReadyCheck プレイヤーが準備確認 (クラス選択・カウントダウンを含む) を取り消す
+func CancelReadyCheck(playerID string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	player, err := lobbyPlayer(playerID)
+	if err != nil {
+		return err
+	}
+	if !cancelReadyCheck(player, "ready_check_cancel") {
+		return ErrLobbyPhase
+	}
+	return nil
+}
+
+// PickClass クラス選択のフェーズでクラスを確定する (空の場合は今のクラスのまま確定する)
+func PickClass(playerID, class string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	player, err := lobbyPlayer(playerID)
+	if err != nil {
+		return err
+	}
+	if class == "" {
+		class = player.Class
+	}
+	if _, ok := classRules(class); !ok {
+		return ErrUnknownClass
+	}
+	if lobby.phase != LobbyPick || lobby.picked[player.ID] {
+		return ErrLobbyPhase
+	}
+
+	applyClass(player, class)
+	lobby.picked[player.ID] = true
+	log.Printf("Player %s picked %s", player.ID, player.Class)
+	emitGameEvent("class_picked", player, nil, 0)
+
+	if allLobbySlots(lobby.picked) {
+		startMatchCountdown()
+		return nil
+	}
+	updateGameState()
+	return nil
+}
+
+// LobbyPlayerByDevice デバイスが紐付けられているプレイヤースロットを取得
+func LobbyPlayerByDevice(deviceID string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	slot, ok := playerSlotByDevice(deviceID)
+	if !ok {
+		return "", ErrDeviceBindingAbsent
+	}
+	return slot, nil
+}
+
+// LobbyPlayerByUser ユーザーが参加しているプレイヤースロットを取得
+func LobbyPlayerByUser(userID string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, slot := range lobbySlots {
+		if p := players[slot]; p != nil && userID != "" && p.UserID == userID {
+			return slot, nil
+		}
+	}
+	return "", ErrSlotEmpty
+}
+
+// スロットに参加しているプレイヤーを取得
+func lobbyPlayer(playerID string) (*Player, error) {
+	if _, ok := opponentSlots[playerID]; !ok {
+		return nil, ErrInvalidPlayerSlot
+	}
+	player := players[playerID]
+	if player == nil {
+		return nil, ErrSlotEmpty
+	}
+	return player, nil
+}
+
+func startReadyCheck() error {
+	switch lobbyPhase() {
+	case LobbyWaiting:
+		return ErrLobbyNotFull
+	case LobbyOpen:
+	default:
+		return ErrLobbyPhase
+	}
+
+	lobby.phase = LobbyReadyCheck
+	lobby.ready = map[string]bool{}
+	lobby.picked = map[string]bool{}
+	startLobbyTimer(rules.Lobby.ReadyCheckMs, func() {
+		log.Printf("Ready check timed out")
+		cancelReadyCheck(nil, "ready_check_timeout")
+	})
+	log.Printf("Ready check started")
+	emitGameEvent("ready_check_start", nil, nil, int(rules.Lobby.ReadyCheckMs))
+
+	// すでにデバイスから準備完了を送っているプレイヤーは準備完了とする
+	for _, slot := range lobbySlots {
+		if players[slot].State == "ready" {
+			confirmReady(players[slot])
+		}
+	}
+	if lobby.phase == LobbyReadyCheck {
+		updateGameState()
+	}
+	return nil
+}
+
+func confirmReady(player *Player) error {
+	if lobbyPhase() == LobbyOpen {
+		if err := startReadyCheck(); err != nil {
+			return err
+		}
+	}
+	if lobbyPhase() != LobbyReadyCheck {
+		return ErrLobbyPhase
+	}
+	if lobby.ready[player.ID] {
+		return nil
+	}
+
+	lobby.ready[player.ID] = true
+	log.Printf("Player %s is ready", player.ID)
+	emitGameEvent("player_ready", player, nil, 0)
+
+	if !allLobbySlots(lobby.ready) {
+		updateGameState()
+		return nil
+	}
+	if rules.Lobby.PickPhaseMs > 0 {
+		startPickPhase()
+	} else {
+		startMatchCountdown()
+	}
+	return nil
+}
+
+// 準備確認・クラス選択・カウントダウンを取り消してロビーに戻す (取り消すものがなければ false)
+// 準備完了を送っていたデバイスには、改めて準備完了を送ってもらう
+func cancelReadyCheck(player *Player, reason string) bool {
+	switch lobby.phase {
+	case LobbyReadyCheck, LobbyPick, LobbyCountdown:
+	default:
+		return false
+	}
+
+	resetLobby()
+	for _, slot := range lobbySlots {
+		if p := players[slot]; p != nil {
+			p.Time = 0
+			if p.State == "ready" {
+				p.State = "noReady"
+			}
+		}
+	}
+	emitGameEvent(reason, player, nil, 0)
+	updateGameState()
+	return true
+}
+
+// ロビーを準備確認の前に戻す
+func resetLobby() {
+	lobby.phase = ""
+	lobby.ready = map[string]bool{}
+	lobby.picked = map[string]bool{}
+	lobby.deadline = time.Time{}
+	lobby.round++
+	clearArena()
+	clearPowerUps()
+	for _, player := range players {
+		resetCharge(player)
+	}
+}
+
+func startPickPhase() {
+	lobby.phase = LobbyPick
+	startLobbyTimer(rules.Lobby.PickPhaseMs, func() {
+		// 確定しなかったプレイヤーは今のクラス (ロードアウト) のまま
+		log.Printf("Pick phase timed out")
+		emitGameEvent("pick_timeout", nil, nil, 0)
+		startMatchCountdown()
+	})
+	log.Printf("Pick phase started")
+	emitGameEvent("pick_start", nil, nil, int(rules.Lobby.PickPhaseMs))
+	updateGameState()
+}
+
+// 期限付きのフェーズのタイマーを開始 (フェーズが変わっていれば onTimeout は呼ばれない)
+func startLobbyTimer(ms int64, onTimeout func()) {
+	lobby.round++
+	round := lobby.round
+	d := time.Duration(ms) * time.Millisecond
+	lobby.deadline = time.Now().Add(d)
+
+	time.AfterFunc(d, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if lobby.round == round {
+			onTimeout()
+		}
+	})
+}
+
+// 全員の準備が整ったので、ステータスを初期化してカウントダウンを開始
+func startMatchCountdown() {
+	lobby.phase = LobbyCountdown
+	lobby.deadline = time.Time{}
+	lobby.round++
+	round := lobby.round
+
+	for _, slot := range lobbySlots {
+		p := players[slot]
+		applyClass(p, p.Class)
+		resetCharge(p)
+		p.Action = "none"
+		p.lastHit = nil
+		p.shieldUntil = time.Time{}
+		p.boostUntil = time.Time{}
+		p.Stocks = stockCount()
+		p.respawnAt = time.Time{}
+		p.invulnerableUntil = time.Time{}
+	}
+	GameOver = false
+	log.Printf("All players are ready, starting countdown")
+	chooseArena()
+
+	go runCountdown(round)
+}
+
+// カウントダウンの後に試合を開始 (途中で取り消された場合は何もしない)
+func runCountdown(round int) {
+	for i := rules.Lobby.CountdownSeconds; i > 0; i-- {
+		mu.Lock()
+		if lobby.round != round {
+			mu.Unlock()
+			return
+		}
+		for _, slot := range lobbySlots {
+			players[slot].Time = i
+		}
+		emitGameEvent("countdown", nil, nil, i)
+		updateGameState()
+		mu.Unlock()
+
+		time.Sleep(time.Second)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if lobby.round != round {
+		return
+	}
+
+	lobby.phase = LobbyFighting
+	startArena()
+	startPowerUps()
+	for _, slot := range lobbySlots {
+		players[slot].Time = 0
+		players[slot].State = "fighting"
+	}
+	emitGameEvent("fight_start", nil, nil, 0)
+	updateGameState()
+}
+
+// プレイヤーが参加した (入れ替わった) 場合、準備確認をやり直す (試合中の場合は試合を終える)
+func lobbyPlayerJoined(player *Player) {
+	if lobby.phase == LobbyFighting {
+		resetLobby()
+	}
+	cancelReadyCheck(player, "ready_check_cancel")
+	emitGameEvent("player_joined", player, nil, 0)
+}
+
+func allLobbySlots(done map[string]bool) bool {
+	for _, slot := range lobbySlots {
+		if !done[slot] {
+			return false
+		}
+	}
+	return true
+}
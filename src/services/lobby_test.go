@@ -0,0 +1,118 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLobbyPhases(t *testing.T) {
+	type step struct {
+		name      string
+		do        func() error
+		wantErr   error
+		wantPhase string
+	}
+	ready := func(id string) func() error { return func() error { return ConfirmReady(id) } }
+	cancel := func(id string) func() error { return func() error { return CancelReadyCheck(id) } }
+	pick := func(id, class string) func() error { return func() error { return PickClass(id, class) } }
+
+	tests := []struct {
+		name        string
+		pickPhaseMs int64
+		steps       []step
+	}{
+		{"ready check to countdown", 0, []step{
+			{"start", StartReadyCheck, nil, LobbyReadyCheck},
+			{"start again", StartReadyCheck, ErrLobbyPhase, LobbyReadyCheck},
+			{"player1 ready", ready("player1"), nil, LobbyReadyCheck},
+			{"player1 ready again", ready("player1"), nil, LobbyReadyCheck},
+			{"player2 ready", ready("player2"), nil, LobbyCountdown},
+			{"ready during countdown", ready("player1"), ErrLobbyPhase, LobbyCountdown},
+		}},
+		{"ready starts the ready check", 0, []step{
+			{"player2 ready", ready("player2"), nil, LobbyReadyCheck},
+		}},
+		{"cancel returns to open", 0, []step{
+			{"cancel before ready check", cancel("player1"), ErrLobbyPhase, LobbyOpen},
+			{"player1 ready", ready("player1"), nil, LobbyReadyCheck},
+			{"player2 cancels", cancel("player2"), nil, LobbyOpen},
+		}},
+		{"pick phase", 10000, []step{
+			{"pick before pick phase", pick("player1", "tank"), ErrLobbyPhase, LobbyOpen},
+			{"player1 ready", ready("player1"), nil, LobbyReadyCheck},
+			{"player2 ready", ready("player2"), nil, LobbyPick},
+			{"unknown class", pick("player1", "unknown"), ErrUnknownClass, LobbyPick},
+			{"player1 picks", pick("player1", "tank"), nil, LobbyPick},
+			{"player1 picks again", pick("player1", "mage"), ErrLobbyPhase, LobbyPick},
+			{"player2 picks", pick("player2", ""), nil, LobbyCountdown},
+		}},
+		{"invalid slot", 0, []step{
+			{"player3 ready", ready("player3"), ErrInvalidPlayerSlot, LobbyOpen},
+		}},
+	}
+
+	defer func(ms int64) { rules.Lobby.PickPhaseMs = ms }(rules.Lobby.PickPhaseMs)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestMatch(t)
+			rules.Lobby.PickPhaseMs = tt.pickPhaseMs
+
+			for _, s := range tt.steps {
+				if err := s.do(); err != s.wantErr {
+					t.Errorf("%s: err = %v, want %v", s.name, err, s.wantErr)
+				}
+				mu.Lock()
+				phase := lobbyPhase()
+				mu.Unlock()
+				if phase != s.wantPhase {
+					t.Errorf("%s: phase = %s, want %s", s.name, phase, s.wantPhase)
+				}
+			}
+		})
+	}
+}
+
+func TestLobbyWaiting(t *testing.T) {
+	setupTestMatch(t)
+
+	mu.Lock()
+	delete(players, "player2")
+	mu.Unlock()
+
+	if err := StartReadyCheck(); err != ErrLobbyNotFull {
+		t.Errorf("StartReadyCheck() = %v, want %v", err, ErrLobbyNotFull)
+	}
+	if phase := GetLobby().Phase; phase != LobbyWaiting {
+		t.Errorf("phase = %s, want %s", phase, LobbyWaiting)
+	}
+}
+
+func TestCountdownStartsFight(t *testing.T) {
+	player, _ := setupTestMatch(t)
+	defer func(seconds int) { rules.Lobby.CountdownSeconds = seconds }(rules.Lobby.CountdownSeconds)
+	rules.Lobby.CountdownSeconds = 0
+
+	for _, id := range lobbySlots {
+		if err := ConfirmReady(id); err != nil {
+			t.Fatalf("ConfirmReady(%s) = %v", id, err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		phase, state := lobbyPhase(), player.State
+		mu.Unlock()
+		if phase == LobbyFighting {
+			if state != "fighting" {
+				t.Errorf("player state = %s, want fighting", state)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("phase = %s, want %s", phase, LobbyFighting)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"md2s/repositorys"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// ペアリングコードの有効期限
+	pairingCodeTTL = 2 * time.Minute
+	// ペアリングに失敗できる回数 (超えると発行済みのコードを全て無効にする)
+	maxPairingAttempts = 5
+)
+
+var (
+	ErrInvalidPlayerSlot   = errors.New("invalid player slot")
+	ErrInvalidPairingCode  = errors.New("invalid or expired pairing code")
+	ErrDeviceBindingAbsent = errors.New("device is not paired")
+)
+
+// プレイヤースロット (対戦相手は必ずもう一方のスロット)
+var opponentSlots = map[string]string{
+	"player1": "player2",
+	"player2": "player1",
+}
+
+// ペアリングコード
+type PairingCode struct {
+	Code      string    `json:"code"`
+	PlayerID  string    `json:"playerId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// デバイスとプレイヤースロットの紐付け
+type DeviceBinding struct {
+	DeviceID string    `json:"deviceId"`
+	PlayerID string    `json:"playerId"`
+	PairedAt time.Time `json:"pairedAt"`
+}
+
+// 既存のファームウェアのデバイスID (LEGACY_DEVICE_IDS=true の場合のみ最初から紐付ける)
+var legacyDeviceBindings = map[string]string{
+	"1": "player1",
+	"2": "player2",
+}
+
+var (
+	deviceBindings = map[string]*DeviceBinding{} // デバイスID → 紐付け (デバイスの登録情報に保存する)
+	pairingCodes   = map[string]*PairingCode{}   // コード → ペアリングコード
+	// コードを発行してから失敗したペアリングの回数 (総当たりを防ぐ)
+	pairingFailures int
+)
+
+// LoadDeviceBindings 保存されたデバイスの紐付けを読み込む (起動時に呼ぶ)
+// LEGACY_DEVICE_IDS=true の場合は、紐付いていないスロットにデバイス "1" と "2" を紐付ける (保存はしない)
+func LoadDeviceBindings() error {
+	saved, err := repositorys.GetDeviceBindings()
+	if err != nil {
+		log.Printf("Failed to load device bindings: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, device := range saved {
+		if _, ok := opponentSlots[device.PairedPlayer]; !ok {
+			continue
+		}
+		binding := &DeviceBinding{DeviceID: device.ID, PlayerID: device.PairedPlayer}
+		if device.PairedAt != nil {
+			binding.PairedAt = *device.PairedAt
+		}
+		deviceBindings[device.ID] = binding
+	}
+
+	if os.Getenv("LEGACY_DEVICE_IDS") == "true" {
+		for deviceID, playerID := range legacyDeviceBindings {
+			if _, paired := deviceBySlot(playerID); !paired {
+				deviceBindings[deviceID] = &DeviceBinding{DeviceID: deviceID, PlayerID: playerID, PairedAt: time.Now()}
+			}
+		}
+	}
+	return err
+}
+
+// スロットに紐付いたデバイスを取得 (mu を持って呼ぶ)
+func deviceBySlot(playerID string) (string, bool) {
+	for id, b := range deviceBindings {
+		if b.PlayerID == playerID {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// CreatePairingCode プレイヤースロット用のペアリングコードを発行 (以前のコードは無効になる)
+func CreatePairingCode(playerID string) (*PairingCode, error) {
+	if _, ok := opponentSlots[playerID]; !ok {
+		return nil, ErrInvalidPlayerSlot
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	for code, p := range pairingCodes {
+		if p.PlayerID == playerID || now.After(p.ExpiresAt) {
+			delete(pairingCodes, code)
+		}
+	}
+
+	// 有効なコードと重複しない6桁のコードを生成
+	var code string
+	for {
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			return nil, err
+		}
+		code = fmt.Sprintf("%06d", n.Int64())
+		if _, exists := pairingCodes[code]; !exists {
+			break
+		}
+	}
+
+	p := &PairingCode{Code: code, PlayerID: playerID, ExpiresAt: now.Add(pairingCodeTTL)}
+	pairingCodes[code] = p
+	pairingFailures = 0
+	return p, nil
+}
+
+// PairDevice ペアリングコードでデバイスをプレイヤースロットに紐付ける
+// 同じスロットに紐付いていた他のデバイスは解除される (コントローラーの交換)
+// デバイスの種類に既定の入力プロファイルがあれば割り当てる
+// 紐付けはデバイスの登録情報に保存し、再起動後も保持する
+func PairDevice(deviceID, code string) (*DeviceBinding, error) {
+	binding, replaced, err := pairDevice(deviceID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// 保存できなくても紐付けは有効 (再起動すると失われる)
+	for _, id := range replaced {
+		if err := repositorys.ClearDeviceBinding(id); err != nil {
+			log.Printf("Failed to clear binding of device %s: %v", id, err)
+		}
+	}
+	if err := repositorys.SaveDeviceBinding(binding.DeviceID, binding.PlayerID, binding.PairedAt); err != nil {
+		log.Printf("Failed to save binding of device %s: %v", deviceID, err)
+	}
+
+	assignDefaultInputProfile(deviceID)
+	return binding, nil
+}
+
+// 紐付けたデバイスと、同じスロットから解除したデバイスを返す
+func pairDevice(deviceID, code string) (*DeviceBinding, []string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	p, exists := pairingCodes[code]
+	if !exists || time.Now().After(p.ExpiresAt) {
+		pairingFailures++
+		if pairingFailures >= maxPairingAttempts && len(pairingCodes) > 0 {
+			log.Printf("Too many failed pairing attempts, invalidating all pairing codes")
+			pairingCodes = map[string]*PairingCode{}
+		}
+		return nil, nil, ErrInvalidPairingCode
+	}
+	delete(pairingCodes, code)
+
+	var replaced []string
+	for id, b := range deviceBindings {
+		if b.PlayerID != p.PlayerID {
+			continue
+		}
+		delete(deviceBindings, id)
+		if id != deviceID {
+			replaced = append(replaced, id)
+			forgetDeviceClock(id)
+		}
+		log.Printf("Device %s unpaired from %s", id, b.PlayerID)
+	}
+
+	binding := &DeviceBinding{DeviceID: deviceID, PlayerID: p.PlayerID, PairedAt: time.Now()}
+	deviceBindings[deviceID] = binding
+	log.Printf("Device %s paired with %s", deviceID, p.PlayerID)
+
+	emitGameEvent("device_paired", players[p.PlayerID], nil, 0)
+	return binding, replaced, nil
+}
+
+// ListDeviceBindings デバイスの紐付け一覧を取得
+func ListDeviceBindings() []DeviceBinding {
+	mu.Lock()
+	defer mu.Unlock()
+
+	bindings := make([]DeviceBinding, 0, len(deviceBindings))
+	for _, b := range deviceBindings {
+		bindings = append(bindings, *b)
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].PlayerID < bindings[j].PlayerID
+	})
+	return bindings
+}
+
+// RevokeDeviceBinding デバイスの紐付けを解除 (保存した紐付けも削除する)
+func RevokeDeviceBinding(deviceID string) error {
+	if err := revokeDeviceBinding(deviceID); err != nil {
+		return err
+	}
+	return repositorys.ClearDeviceBinding(deviceID)
+}
+
+func revokeDeviceBinding(deviceID string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := deviceBindings[deviceID]; !exists {
+		return ErrDeviceBindingAbsent
+	}
+	delete(deviceBindings, deviceID)
+	forgetDeviceClock(deviceID)
+	log.Printf("Device %s binding revoked", deviceID)
+	return nil
+}
+
+// DevicePaired デバイスがプレイヤースロットにペアリングされているか
+func DevicePaired(deviceID string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	_, exists := deviceBindings[deviceID]
+	return exists
+}
+
+// デバイスIDに対応するプレイヤースロットを取得
+func playerSlotByDevice(deviceID string) (string, bool) {
+	b, exists := deviceBindings[deviceID]
+	if !exists {
+		return "", false
+	}
+	return b.PlayerID, true
+}
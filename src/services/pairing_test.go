@@ -0,0 +1,137 @@
+package services
+
+import (
+	"md2s/repositorys"
+	"testing"
+)
+
+// 紐付けとペアリングコードを空にする (テストの終わりに元に戻し、保存した紐付けも削除する)
+func resetTestBindings(t *testing.T, deviceIDs ...string) {
+	t.Helper()
+	mu.Lock()
+	defer mu.Unlock()
+
+	savedBindings, savedCodes := deviceBindings, pairingCodes
+	deviceBindings = map[string]*DeviceBinding{}
+	pairingCodes = map[string]*PairingCode{}
+	pairingFailures = 0
+	t.Cleanup(func() {
+		for _, id := range deviceIDs {
+			repositorys.DeleteDevice(id)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		deviceBindings, pairingCodes = savedBindings, savedCodes
+	})
+}
+
+// 再起動をまねて、紐付けを保存したものだけにする
+func reloadTestBindings(t *testing.T) {
+	t.Helper()
+	mu.Lock()
+	deviceBindings = map[string]*DeviceBinding{}
+	mu.Unlock()
+	if err := LoadDeviceBindings(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPairDevice(t *testing.T) {
+	resetTestBindings(t, "test-pair-a", "test-pair-b")
+
+	if _, err := CreatePairingCode("player3"); err != ErrInvalidPlayerSlot {
+		t.Fatalf("CreatePairingCode(player3) = %v, want %v", err, ErrInvalidPlayerSlot)
+	}
+
+	code, err := CreatePairingCode("player1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PairDevice("test-pair-a", "not-a-code"); err != ErrInvalidPairingCode {
+		t.Fatalf("PairDevice(invalid code) = %v, want %v", err, ErrInvalidPairingCode)
+	}
+	binding, err := PairDevice("test-pair-a", code.Code)
+	if err != nil || binding.PlayerID != "player1" {
+		t.Fatalf("PairDevice() = %+v, %v, want player1", binding, err)
+	}
+	if _, err := PairDevice("test-pair-b", code.Code); err != ErrInvalidPairingCode {
+		t.Fatalf("PairDevice(used code) = %v, want %v", err, ErrInvalidPairingCode)
+	}
+
+	// 同じスロットに別のデバイスをペアリングすると、以前のデバイスは解除される
+	code, err = CreatePairingCode("player1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PairDevice("test-pair-b", code.Code); err != nil {
+		t.Fatal(err)
+	}
+	if DevicePaired("test-pair-a") || !DevicePaired("test-pair-b") {
+		t.Fatalf("bindings = %+v, want only test-pair-b", ListDeviceBindings())
+	}
+
+	// 再起動しても紐付けは残る
+	reloadTestBindings(t)
+	if DevicePaired("test-pair-a") || !DevicePaired("test-pair-b") {
+		t.Fatalf("bindings after reload = %+v, want only test-pair-b", ListDeviceBindings())
+	}
+
+	// 解除した紐付けは再起動後も戻らない
+	if err := RevokeDeviceBinding("test-pair-b"); err != nil {
+		t.Fatal(err)
+	}
+	reloadTestBindings(t)
+	if DevicePaired("test-pair-b") {
+		t.Fatalf("revoked binding was restored: %+v", ListDeviceBindings())
+	}
+}
+
+func TestPairDeviceTooManyFailures(t *testing.T) {
+	resetTestBindings(t, "test-pair-brute")
+
+	code, err := CreatePairingCode("player2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxPairingAttempts; i++ {
+		PairDevice("test-pair-brute", "wrong")
+	}
+
+	// 失敗が続いたら発行済みのコードも使えない
+	if _, err := PairDevice("test-pair-brute", code.Code); err != ErrInvalidPairingCode {
+		t.Fatalf("PairDevice() after %d failures = %v, want %v", maxPairingAttempts, err, ErrInvalidPairingCode)
+	}
+}
+
+func TestLoadDeviceBindingsLegacy(t *testing.T) {
+	tests := []struct {
+		name   string
+		legacy string
+		want   map[string]bool
+	}{
+		{"既定では固定のIDを紐付けない", "", map[string]bool{"1": false, "2": false, "test-pair-saved": true}},
+		{"LEGACY_DEVICE_IDS=true で空いたスロットに紐付ける", "true", map[string]bool{"1": false, "2": true, "test-pair-saved": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTestBindings(t, "test-pair-saved")
+			t.Setenv("LEGACY_DEVICE_IDS", tt.legacy)
+
+			code, err := CreatePairingCode("player1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := PairDevice("test-pair-saved", code.Code); err != nil {
+				t.Fatal(err)
+			}
+
+			reloadTestBindings(t)
+			for deviceID, want := range tt.want {
+				if got := DevicePaired(deviceID); got != want {
+					t.Errorf("DevicePaired(%q) = %v, want %v", deviceID, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"log"
+	"math"
+	"time"
+)
+
+// 防御の結果
+const (
+	defenseNone  = iota // 間に合っていない
+	defenseParry        // 攻撃の直前の防御 (反撃する)
+	defenseBlock        // 防御していた
+	defenseLate         // 攻撃の後の防御 (ダメージを一部だけ防ぐ)
+)
+
+// 防御した時刻と攻撃の時刻から防御の結果を判定 (どちらも補正後の時刻)
+func defenseOutcome(defendAt, attackAt time.Time) int {
+	d := attackAt.Sub(defendAt)
+	switch {
+	case d < -rules.lagTolerance():
+		return defenseNone
+	case d < 0:
+		return defenseLate
+	case d <= rules.parryWindow():
+		return defenseParry
+	default:
+		return defenseBlock
+	}
+}
+
+// パリィ: 攻撃を無効にしてダメージの一部を反撃し、防御に使ったDFを戻す
+func parry(defender, attacker *Player, damage int) {
+	counter := int(math.Round(float64(damage) * rules.ParryCounterRatio))
+	attacker.HP -= counter
+	if attacker.HP < 0 {
+		attacker.HP = 0
+	}
+	defender.DF += arenaCost(defender.class.DefendCost, arenaDefendCost)
+	if defender.DF > defender.class.DF {
+		defender.DF = defender.class.DF
+	}
+
+	log.Printf("Player %s parried Player %s's attack and countered for %d damage", defender.ID, attacker.ID, counter)
+	emitGameEvent("parry", defender, attacker, counter)
+	knockOut(attacker, defender)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefenseOutcome(t *testing.T) {
+	attackAt := time.Now()
+	tolerance := rules.lagTolerance()
+	parryWindow := rules.parryWindow()
+
+	tests := []struct {
+		name     string
+		defendAt time.Time
+		want     int
+	}{
+		{"too late", attackAt.Add(tolerance + time.Millisecond), defenseNone},
+		{"late at tolerance", attackAt.Add(tolerance), defenseLate},
+		{"late", attackAt.Add(time.Millisecond), defenseLate},
+		{"parry at attack", attackAt, defenseParry},
+		{"parry at window edge", attackAt.Add(-parryWindow), defenseParry},
+		{"block", attackAt.Add(-parryWindow - time.Millisecond), defenseBlock},
+		{"block long before", attackAt.Add(-time.Minute), defenseBlock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defenseOutcome(tt.defendAt, attackAt); got != tt.want {
+				t.Errorf("defenseOutcome() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAttackDefense(t *testing.T) {
+	attackAt := time.Now()
+	base := 20
+
+	tests := []struct {
+		name         string
+		action       string
+		defendAt     time.Time
+		state        string
+		wantTargetHP int
+		wantAttackHP int
+	}{
+		{"no defense", "none", time.Time{}, "fighting", 80, 100},
+		{"block", "defend", attackAt.Add(-time.Second), "fighting", 100, 100},
+		{"parry counters", "defend", attackAt, "fighting", 100, 90},
+		{"late defense blocks half", "defend", attackAt.Add(time.Millisecond), "fighting", 90, 100},
+		{"guard broken takes extra damage", "defend", attackAt.Add(-time.Second), "guardBroken", 70, 100},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attacker := newTestPlayer("attacker")
+			target := newTestPlayer("target")
+			target.Action = tt.action
+			target.ActionAt = tt.defendAt
+			target.State = tt.state
+
+			resolveAttack(attacker, target, attackAt, base, 0)
+
+			if target.HP != tt.wantTargetHP {
+				t.Errorf("target HP = %d, want %d", target.HP, tt.wantTargetHP)
+			}
+			if attacker.HP != tt.wantAttackHP {
+				t.Errorf("attacker HP = %d, want %d", attacker.HP, tt.wantAttackHP)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInputPower(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name  string
+		power *float64
+		want  float64
+	}{
+		{"指定なしは最大", nil, 1},
+		{"NaN は最大", value(math.NaN()), 1},
+		{"範囲内はそのまま", value(0.3), 0.3},
+		{"負の値は0", value(-0.5), 0},
+		{"1を超える値は1", value(2), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inputPower(tt.power); got != tt.want {
+				t.Errorf("inputPower() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPowerCurveApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		curve PowerCurve
+		power float64
+		want  float64
+	}{
+		{"最小の強さ", PowerCurve{Min: 0.5, Max: 1, Exponent: 1}, 0, 0.5},
+		{"最大の強さ", PowerCurve{Min: 0.5, Max: 1, Exponent: 1}, 1, 1},
+		{"線形", PowerCurve{Min: 0.5, Max: 1, Exponent: 1}, 0.5, 0.75},
+		{"指数で弱い入力を抑える", PowerCurve{Min: 0, Max: 2, Exponent: 2}, 0.5, 0.5},
+		{"強さに関係なく一定", PowerCurve{Min: 1, Max: 1, Exponent: 1}, 0.2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.curve.apply(tt.power); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("apply(%v) = %v, want %v", tt.power, got, tt.want)
+			}
+		})
+	}
+}
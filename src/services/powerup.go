@@ -0,0 +1,330 @@
+package services
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// パワーアップの種類
+const (
+	powerUpHeal        = "heal"         // HPを回復
+	powerUpMPSurge     = "mp_surge"     // MPを回復
+	powerUpShield      = "shield"       // 一定時間受けるダメージが減る
+	powerUpDamageBoost = "damage_boost" // 一定時間与えるダメージが増える
+)
+
+var powerUpKinds = []string{powerUpHeal, powerUpMPSurge, powerUpShield, powerUpDamageBoost}
+
+// 出現・期限切れを確認する間隔
+const powerUpTickInterval = 100 * time.Millisecond
+
+// PowerUpStatus 出現中のパワーアップ
+type PowerUpStatus struct {
+	Kind      string    `json:"kind"`
+	SpawnedAt time.Time `json:"spawnedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// PowerUpsStatus 試合のパワーアップの状態
+type PowerUpsStatus struct {
+	Seed     uint64         `json:"seed"`     // 試合のシード (試合前は次の試合に指定されたシード)
+	Spawned  int            `json:"spawned"`  // この試合で出現した数
+	Active   *PowerUpStatus `json:"active"`   // 出現中のパワーアップ
+	NextSeed uint64         `json:"nextSeed"` // 管理者が指定した次の試合のシード
+}
+
+// 出現中のパワーアップ
+type powerUp struct {
+	id        int
+	kind      string
+	spawnedAt time.Time
+	expiresAt time.Time
+	claims    []powerUpClaim // 取得しようとしたプレイヤー (届いた順)
+}
+
+type powerUpClaim struct {
+	player *Player
+	at     time.Time // 補正後の時刻
+}
+
+// パワーアップの状態 (mu で保護する)
+var powerUps = struct {
+	seed      uint64
+	nextSeed  uint64 // 0 の場合はルール設定に従う
+	rng       *rand.Rand
+	startedAt time.Time // 試合開始時刻 (試合中でなければゼロ値)
+	next      *PowerUpSpawn
+	spawned   int
+	lastAtMs  int64
+	active    *powerUp
+}{}
+
+var powerUpTicker = startPowerUpTicker()
+
+func startPowerUpTicker() *time.Ticker {
+	ticker := time.NewTicker(powerUpTickInterval)
+	go func() {
+		for range ticker.C {
+			tickPowerUps()
+		}
+	}()
+	return ticker
+}
+
+// GetPowerUps 試合のパワーアップの状態を取得
+func GetPowerUps() PowerUpsStatus {
+	mu.Lock()
+	defer mu.Unlock()
+
+	status := PowerUpsStatus{Seed: powerUps.seed, Spawned: powerUps.spawned, NextSeed: powerUps.nextSeed}
+	if p := powerUps.active; p != nil {
+		status.Active = &PowerUpStatus{Kind: p.kind, SpawnedAt: p.spawnedAt, ExpiresAt: p.expiresAt}
+	}
+	return status
+}
+
+// SetNextPowerUpSeed 次の試合のシードを指定 (0 の場合はルール設定に戻す)
+func SetNextPowerUpSeed(seed uint64) {
+	mu.Lock()
+	defer mu.Unlock()
+	powerUps.nextSeed = seed
+}
+
+// 試合開始 (シードから出現の予定を決める)
+func startPowerUps() {
+	seed := powerUps.nextSeed
+	powerUps.nextSeed = 0
+	if seed == 0 {
+		seed = rules.PowerUps.Seed
+	}
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+
+	powerUps.seed = seed
+	powerUps.rng = rand.New(rand.NewPCG(seed, seed))
+	powerUps.startedAt = time.Now()
+	powerUps.spawned = 0
+	powerUps.lastAtMs = 0
+	powerUps.active = nil
+	powerUps.next = nextPowerUpSpawn()
+	log.Printf("Power-up seed: %d", seed)
+}
+
+// 試合が終わったのでパワーアップを消す
+func clearPowerUps() {
+	powerUps.startedAt = time.Time{}
+	powerUps.next = nil
+	powerUps.active = nil
+	for _, player := range players {
+		player.shieldUntil = time.Time{}
+		player.boostUntil = time.Time{}
+	}
+}
+
+// 次に出現するパワーアップ (出現しない場合は nil)
+func nextPowerUpSpawn() *PowerUpSpawn {
+	config := rules.PowerUps
+	if len(config.Schedule) > 0 {
+		if powerUps.spawned >= len(config.Schedule) {
+			return nil
+		}
+		spawn := config.Schedule[powerUps.spawned]
+		return &spawn
+	}
+	if config.IntervalMs <= 0 {
+		return nil
+	}
+
+	kinds := config.Kinds
+	if len(kinds) == 0 {
+		kinds = powerUpKinds
+	}
+	at := powerUps.lastAtMs + config.IntervalMs
+	if config.JitterMs > 0 {
+		at += powerUps.rng.Int64N(2*config.JitterMs+1) - config.JitterMs
+	}
+	return &PowerUpSpawn{AtMs: at, Kind: kinds[powerUps.rng.IntN(len(kinds))]}
+}
+
+// パワーアップの出現・期限切れと、効果の期限切れを処理して配信する
+func tickPowerUps() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if powerUps.startedAt.IsZero() || GameOver {
+		return
+	}
+
+	now := time.Now()
+	changed := false
+
+	// 取得しようとしたプレイヤーがいる場合は resolvePowerUp に任せる
+	if p := powerUps.active; p != nil && len(p.claims) == 0 && now.After(p.expiresAt) {
+		powerUps.active = nil
+		log.Printf("Power-up %s expired", p.kind)
+		emitGameEvent("powerup_expired", nil, nil, 0)
+		changed = true
+	}
+
+	if next := powerUps.next; next != nil && now.Sub(powerUps.startedAt).Milliseconds() >= next.AtMs && powerUps.active == nil {
+		spawnPowerUp(*next, now)
+		changed = true
+	}
+
+	for _, slot := range lobbySlots {
+		player := players[slot]
+		if player == nil {
+			continue
+		}
+		if !player.shieldUntil.IsZero() && now.After(player.shieldUntil) {
+			player.shieldUntil = time.Time{}
+			emitGameEvent("shield_expired", player, nil, 0)
+			changed = true
+		}
+		if !player.boostUntil.IsZero() && now.After(player.boostUntil) {
+			player.boostUntil = time.Time{}
+			emitGameEvent("damage_boost_expired", player, nil, 0)
+			changed = true
+		}
+	}
+
+	if changed {
+		updateGameState()
+	}
+}
+
+func spawnPowerUp(spawn PowerUpSpawn, now time.Time) {
+	if !slices.Contains(powerUpKinds, spawn.Kind) {
+		log.Printf("Unknown power-up kind: %s", spawn.Kind)
+	} else {
+		powerUps.active = &powerUp{
+			id:        powerUps.spawned,
+			kind:      spawn.Kind,
+			spawnedAt: now,
+			expiresAt: now.Add(time.Duration(rules.PowerUps.WindowMs) * time.Millisecond),
+		}
+		log.Printf("Power-up %s spawned", spawn.Kind)
+		emitGameEvent("powerup_spawn", nil, nil, int(rules.PowerUps.WindowMs))
+	}
+
+	powerUps.spawned++
+	powerUps.lastAtMs = spawn.AtMs
+	powerUps.next = nextPowerUpSpawn()
+}
+
+// パワーアップを取得しようとする (at は補正後の時刻)
+// 遅れて届いた相手の取得と比べるため、最初の取得から許容時間だけ待ってから取得したプレイヤーを決める
+func claimPowerUp(player *Player, at time.Time) {
+	p := powerUps.active
+	if p == nil || at.After(p.expiresAt) || at.Before(p.spawnedAt.Add(-rules.lagTolerance())) {
+		log.Printf("Player %s tried to claim a power-up, but none was available", player.ID)
+		emitGameEvent("powerup_missed", player, nil, 0)
+		return
+	}
+	for _, c := range p.claims {
+		if c.player == player {
+			return
+		}
+	}
+
+	p.claims = append(p.claims, powerUpClaim{player: player, at: at})
+	if len(p.claims) > 1 {
+		return
+	}
+	id := p.id
+	time.AfterFunc(rules.lagTolerance(), func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if powerUps.active != nil && powerUps.active.id == id {
+			resolvePowerUp()
+		}
+	})
+}
+
+// 最も早く取得しようとしたプレイヤーにパワーアップを与える (同時の場合は先に届いた方)
+// 待っている間に倒された・復活待ちになったプレイヤーは取得できない
+func resolvePowerUp() {
+	p := powerUps.active
+	powerUps.active = nil
+	if GameOver {
+		return
+	}
+
+	var winner *powerUpClaim
+	for i, c := range p.claims {
+		if !c.player.canClaimPowerUp() {
+			continue
+		}
+		if winner == nil || c.at.Before(winner.at) {
+			winner = &p.claims[i]
+		}
+	}
+	if winner == nil {
+		log.Printf("Power-up %s was lost, no player could claim it", p.kind)
+		emitGameEvent("powerup_expired", nil, nil, 0)
+		updateGameState()
+		return
+	}
+	player := winner.player
+	var opponent *Player
+	for _, c := range p.claims {
+		if c.player != player {
+			opponent = c.player
+		}
+	}
+
+	config := rules.PowerUps
+	now := time.Now()
+	switch p.kind {
+	case powerUpHeal:
+		player.HP = min(player.class.HP, player.HP+config.HealAmount)
+	case powerUpMPSurge:
+		player.MP = min(player.class.MP, player.MP+config.MPAmount)
+	case powerUpShield:
+		player.shieldUntil = now.Add(time.Duration(config.ShieldMs) * time.Millisecond)
+	case powerUpDamageBoost:
+		player.boostUntil = now.Add(time.Duration(config.BoostMs) * time.Millisecond)
+	}
+
+	log.Printf("Player %s claimed power-up %s", player.ID, p.kind)
+	emitGameEvent("powerup_claimed", player, opponent, 0)
+	updateGameState()
+}
+
+// パワーアップを取得できる状態か
+func (p *Player) canClaimPowerUp() bool {
+	if p.knockedOut() {
+		return false
+	}
+	return p.State == "fighting" || p.State == "guardBroken"
+}
+
+// パワーアップの効果によるダメージの倍率
+func powerUpDamageFactor(attacker, target *Player) float64 {
+	now := time.Now()
+	factor := 1.0
+	if now.Before(attacker.boostUntil) {
+		factor *= rules.PowerUps.BoostMultiplier
+	}
+	if now.Before(target.shieldUntil) {
+		factor *= 1 - rules.PowerUps.ShieldReduction
+	}
+	return math.Max(0, factor)
+}
+
+// プレイヤーに効いているパワーアップの効果
+func (p *Player) buffs() []string {
+	now := time.Now()
+	buffs := []string{}
+	if now.Before(p.shieldUntil) {
+		buffs = append(buffs, powerUpShield)
+	}
+	if now.Before(p.boostUntil) {
+		buffs = append(buffs, powerUpDamageBoost)
+	}
+	return buffs
+}
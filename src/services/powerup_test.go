@@ -0,0 +1,63 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolvePowerUp(t *testing.T) {
+	at := time.Now()
+	early, late := at, at.Add(50*time.Millisecond)
+
+	tests := []struct {
+		name       string
+		claims     []string // 届いた順
+		claimAt    map[string]time.Time
+		states     map[string]string
+		knockedOut map[string]bool
+		want       string // 取得したプレイヤー (空の場合は誰も取得しない)
+	}{
+		{"single claim", []string{"player1"}, map[string]time.Time{"player1": early}, nil, nil, "player1"},
+		{"earliest claim wins", []string{"player1", "player2"}, map[string]time.Time{"player1": late, "player2": early}, nil, nil, "player2"},
+		{"same time goes to first received", []string{"player2", "player1"}, map[string]time.Time{"player1": early, "player2": early}, nil, nil, "player2"},
+		{"respawning winner loses", []string{"player1", "player2"}, map[string]time.Time{"player1": early, "player2": late}, map[string]string{"player1": "respawning"}, nil, "player2"},
+		{"knocked out winner loses", []string{"player1", "player2"}, map[string]time.Time{"player1": early, "player2": late}, nil, map[string]bool{"player1": true}, "player2"},
+		{"guard broken can claim", []string{"player1"}, map[string]time.Time{"player1": early}, map[string]string{"player1": "guardBroken"}, nil, "player1"},
+		{"nobody can claim", []string{"player1"}, map[string]time.Time{"player1": early}, map[string]string{"player1": "respawning"}, nil, ""},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &powerUp{kind: powerUpHeal, spawnedAt: at, expiresAt: at.Add(time.Second)}
+			byID := map[string]*Player{}
+			for _, id := range tt.claims {
+				player := newTestPlayer(id)
+				player.HP = 50
+				if state, ok := tt.states[id]; ok {
+					player.State = state
+				}
+				if tt.knockedOut[id] {
+					player.HP = 0
+				}
+				byID[id] = player
+				p.claims = append(p.claims, powerUpClaim{player: player, at: tt.claimAt[id]})
+			}
+			powerUps.active = p
+
+			resolvePowerUp()
+
+			if powerUps.active != nil {
+				t.Errorf("power-up is still active")
+			}
+			for id, player := range byID {
+				healed := player.HP > 50
+				if healed != (id == tt.want) {
+					t.Errorf("player %s healed = %v, want %v", id, healed, id == tt.want)
+				}
+			}
+		})
+	}
+}
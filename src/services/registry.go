@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"md2s/dto"
+	"md2s/models"
+	"md2s/repositorys"
+	"sync"
+	"time"
+)
+
+// 最終接続時刻をDBに書き込む間隔 (入力のたびに書き込まないようにする)
+const deviceTouchInterval = 10 * time.Second
+
+// ヘルス判定のしきい値
+const (
+	deviceOfflineAfter   = 5 * time.Minute
+	batteryWarningLevel  = 25
+	batteryCriticalLevel = 10
+	rssiWarningLevel     = -80
+	errorWarningCount    = 5
+	errorCriticalCount   = 20
+)
+
+// デバイスのヘルス状態
+const (
+	DeviceHealthOK       = "ok"
+	DeviceHealthWarning  = "warning"
+	DeviceHealthCritical = "critical"
+	DeviceHealthOffline  = "offline"
+)
+
+var (
+	ErrInvalidOwner  = errors.New("invalid owner ID")
+	ErrUnknownDevice = errors.New("unknown device")
+)
+
+// 一覧に表示するデバイスの状態
+type DeviceStatus struct {
+	models.Device
+	Health    string `json:"health"`
+	Connected bool   `json:"connected"` // WebSocketで接続中か
+	PlayerID  string `json:"playerId"`  // ペアリングされているプレイヤースロット
+}
+
+var (
+	deviceTouchedAt   = map[string]time.Time{}
+	deviceTouchedAtMu sync.Mutex
+)
+
+// SaveDeviceInfo デバイスの名前・所有者・種類を登録
+func SaveDeviceInfo(deviceID string, info dto.DeviceInfo) (*models.Device, error) {
+	device := &models.Device{ID: deviceID, Name: info.Name, Type: info.Type, Group: info.Group}
+	if device.Group == "" {
+		device.Group = defaultDeviceGroup
+	}
+	if info.OwnerID != "" {
+		ownerID, err := models.StringToUUID(info.OwnerID)
+		if err != nil {
+			return nil, ErrInvalidOwner
+		}
+		device.OwnerID = &ownerID
+	}
+
+	if err := repositorys.SaveDevice(device); err != nil {
+		return nil, err
+	}
+	forgetDeviceType(deviceID)
+	return repositorys.GetDevice(deviceID)
+}
+
+// DeleteDevice デバイスを登録から削除
+func DeleteDevice(deviceID string) error {
+	credentialsMu.Lock()
+	delete(deviceSecrets, deviceID)
+	delete(usedNonces, deviceID)
+	credentialsMu.Unlock()
+	forgetDeviceType(deviceID)
+	return repositorys.DeleteDevice(deviceID)
+}
+
+// KnownDevice ペアリング済み・シークレット発行済み・登録済みのいずれかのデバイスか
+// 不明なデバイスIDで登録情報や状態を作らないように使う (mu を持たずに呼ぶ)
+func KnownDevice(deviceID string) bool {
+	if DevicePaired(deviceID) {
+		return true
+	}
+	if _, exists := DeviceSecret(deviceID); exists {
+		return true
+	}
+	_, err := repositorys.GetDevice(deviceID)
+	return err == nil
+}
+
+// RecordDeviceTelemetry デバイスのテレメトリを記録 (不明なデバイスは登録しない)
+func RecordDeviceTelemetry(telemetry dto.DeviceTelemetry) error {
+	if !KnownDevice(telemetry.DeviceID) {
+		return ErrUnknownDevice
+	}
+
+	values := map[string]interface{}{"last_seen_at": time.Now()}
+	if telemetry.BatteryLevel != nil {
+		values["battery_level"] = *telemetry.BatteryLevel
+	}
+	if telemetry.RSSI != nil {
+		values["rssi"] = *telemetry.RSSI
+	}
+	if telemetry.FirmwareVersion != "" {
+		values["firmware_version"] = telemetry.FirmwareVersion
+	}
+	if telemetry.ErrorCount != nil {
+		values["error_count"] = *telemetry.ErrorCount
+	}
+
+	return repositorys.UpsertDeviceColumns(telemetry.DeviceID, values)
+}
+
+// ListDeviceFleet 登録済みのデバイスとヘルス状態の一覧を取得
+func ListDeviceFleet() ([]DeviceStatus, error) {
+	registered, err := repositorys.GetDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	fleet := make([]DeviceStatus, 0, len(registered))
+	for _, device := range registered {
+		status := DeviceStatus{Device: device, Health: deviceHealth(device, now)}
+		if d, exists := devices[device.ID]; exists && d.Conn != nil {
+			status.Connected = true
+		}
+		status.PlayerID, _ = playerSlotByDevice(device.ID)
+		fleet = append(fleet, status)
+	}
+	return fleet, nil
+}
+
+// テレメトリからヘルス状態を判定
+func deviceHealth(device models.Device, now time.Time) string {
+	if device.LastSeenAt == nil || now.Sub(*device.LastSeenAt) > deviceOfflineAfter {
+		return DeviceHealthOffline
+	}
+	if device.BatteryLevel != nil && *device.BatteryLevel <= batteryCriticalLevel || device.ErrorCount >= errorCriticalCount {
+		return DeviceHealthCritical
+	}
+	if device.BatteryLevel != nil && *device.BatteryLevel <= batteryWarningLevel ||
+		device.RSSI != nil && *device.RSSI <= rssiWarningLevel ||
+		device.ErrorCount >= errorWarningCount {
+		return DeviceHealthWarning
+	}
+	return DeviceHealthOK
+}
+
+// デバイスの最終接続時刻を更新 (一定間隔ごとに非同期で書き込む)
+func touchDevice(deviceID string) {
+	now := time.Now()
+
+	deviceTouchedAtMu.Lock()
+	if now.Sub(deviceTouchedAt[deviceID]) < deviceTouchInterval {
+		deviceTouchedAtMu.Unlock()
+		return
+	}
+	deviceTouchedAt[deviceID] = now
+	deviceTouchedAtMu.Unlock()
+
+	go func() {
+		if err := repositorys.TouchDevice(deviceID, now); err != nil {
+			log.Printf("Failed to update last seen of device %s: %v", deviceID, err)
+		}
+	}()
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"md2s/dto"
+	"md2s/models"
+	"md2s/repositorys"
+	"testing"
+	"time"
+)
+
+func TestRecordDeviceTelemetry(t *testing.T) {
+	const known = "test-telemetry-known"
+	if _, err := IssueDeviceSecret(known); err != nil {
+		t.Fatal(err)
+	}
+	defer DeleteDevice(known)
+
+	battery := 80
+	tests := []struct {
+		deviceID string
+		wantErr  error
+	}{
+		{known, nil},
+		{"test-telemetry-unknown", ErrUnknownDevice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.deviceID, func(t *testing.T) {
+			err := RecordDeviceTelemetry(dto.DeviceTelemetry{DeviceID: tt.deviceID, BatteryLevel: &battery})
+			if err != tt.wantErr {
+				t.Fatalf("RecordDeviceTelemetry() = %v, want %v", err, tt.wantErr)
+			}
+
+			device, err := repositorys.GetDevice(tt.deviceID)
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Errorf("unknown device was registered")
+				}
+				return
+			}
+			if err != nil || device.BatteryLevel == nil || *device.BatteryLevel != battery {
+				t.Errorf("device = %+v, %v, want battery %d", device, err, battery)
+			}
+		})
+	}
+}
+
+func TestDeviceHealth(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+	stale := now.Add(-deviceOfflineAfter - time.Second)
+	level := func(n int) *int { return &n }
+
+	tests := []struct {
+		name   string
+		device models.Device
+		want   string
+	}{
+		{"正常", models.Device{LastSeenAt: &recent, BatteryLevel: level(80), RSSI: level(-50)}, DeviceHealthOK},
+		{"接続したことがない", models.Device{}, DeviceHealthOffline},
+		{"しばらく接続していない", models.Device{LastSeenAt: &stale, BatteryLevel: level(80)}, DeviceHealthOffline},
+		{"バッテリー残量が少ない", models.Device{LastSeenAt: &recent, BatteryLevel: level(batteryWarningLevel)}, DeviceHealthWarning},
+		{"バッテリー残量がほとんどない", models.Device{LastSeenAt: &recent, BatteryLevel: level(batteryCriticalLevel)}, DeviceHealthCritical},
+		{"電波が弱い", models.Device{LastSeenAt: &recent, RSSI: level(rssiWarningLevel)}, DeviceHealthWarning},
+		{"エラーが多い", models.Device{LastSeenAt: &recent, ErrorCount: errorWarningCount}, DeviceHealthWarning},
+		{"エラーが非常に多い", models.Device{LastSeenAt: &recent, ErrorCount: errorCriticalCount}, DeviceHealthCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deviceHealth(tt.device, now); got != tt.want {
+				t.Errorf("deviceHealth() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListDeviceFleet(t *testing.T) {
+	const deviceID = "test-fleet-device"
+	if _, err := SaveDeviceInfo(deviceID, dto.DeviceInfo{Name: "fleet", Type: "glove"}); err != nil {
+		t.Fatal(err)
+	}
+	defer DeleteDevice(deviceID)
+	pairTestDevice(t, deviceID, "player2")
+
+	battery := 5
+	if err := RecordDeviceTelemetry(dto.DeviceTelemetry{DeviceID: deviceID, BatteryLevel: &battery}); err != nil {
+		t.Fatal(err)
+	}
+
+	fleet, err := ListDeviceFleet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range fleet {
+		if status.ID != deviceID {
+			continue
+		}
+		if status.Group != defaultDeviceGroup || status.Health != DeviceHealthCritical || status.PlayerID != "player2" {
+			t.Errorf("status = %+v, want group %q, health %q, player2", status, defaultDeviceGroup, DeviceHealthCritical)
+		}
+		return
+	}
+	t.Errorf("%s is not in the fleet: %+v", deviceID, fleet)
+}
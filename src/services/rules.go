@@ -0,0 +1,319 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"os"
+	"time"
+)
+
+// ゲームのルール設定
+// RULES_CONFIG にJSONファイルのパスを指定すると既定値を上書きできる
+type Rules struct {
+	// この時間差 (ミリ秒) 以内の攻撃と防御は防御側を優先する
+	LagToleranceMs int `json:"lagToleranceMs"`
+	// 入力のタイムスタンプで遡ることのできる最大時間 (ミリ秒)
+	MaxLagCompensationMs int `json:"maxLagCompensationMs"`
+	// 攻撃の直前この時間 (ミリ秒) 以内の防御はパリィとなり、ダメージの ParryCounterRatio 倍を反撃する
+	ParryWindowMs     int     `json:"parryWindowMs"`
+	ParryCounterRatio float64 `json:"parryCounterRatio"`
+	// 攻撃の後 (LagToleranceMs 以内) に出された防御で防ぐダメージの割合
+	LateDefenseReduction float64 `json:"lateDefenseReduction"`
+	// IMUのサンプルからジェスチャーを認識する設定
+	Gesture GestureRules `json:"gesture"`
+	// 入力のレート制限と不正検知の設定
+	AntiCheat AntiCheatRules `json:"antiCheat"`
+	// デバイスの種類 → イベント → デバイスに送るコマンド
+	// 種類が "default" の設定と既定のコマンドを上書きする (イベントのキーは commands.go を参照)
+	DeviceCommands map[string]map[string]DeviceCommandConfig `json:"deviceCommands"`
+	// 入力の強さ (power) による効果の倍率
+	Power PowerRules `json:"power"`
+	// 溜め攻撃
+	Charge ChargeRules `json:"charge"`
+	// DFを使い切った時のガードブレイク
+	GuardBreak GuardBreakRules `json:"guardBreak"`
+	// キャラクタークラス (既定のクラスに項目ごとに重ねる・追加する、class.go を参照)
+	Classes map[string]ClassRules `json:"classes"`
+	// 試合前のロビー
+	Lobby LobbyRules `json:"lobby"`
+	// 試合ごとのアリーナの特殊ルール
+	Arena ArenaRules `json:"arena"`
+	// アリーナの特殊ルール (既定の特殊ルールを上書き・追加する、arena.go を参照)
+	ArenaModifiers map[string]ArenaModifier `json:"arenaModifiers"`
+	// 試合中に出現するパワーアップ
+	PowerUps PowerUpRules `json:"powerUps"`
+	// 属性
+	Elements ElementRules `json:"elements"`
+	// ストック制
+	Stocks StockRules `json:"stocks"`
+}
+
+// ストック制の設定
+// Count が 2 以上の場合、HPが0になってもストックが残っていれば RespawnMs 後にステータスを戻して復活し、
+// その後 InvulnerableMs の間は攻撃を受けない。ストックを使い切ると負け (1 以下はHPのみの試合)
+type StockRules struct {
+	Count          int   `json:"count"`
+	RespawnMs      int64 `json:"respawnMs"`
+	InvulnerableMs int64 `json:"invulnerableMs"`
+}
+
+// 属性の設定
+// 攻撃の属性 → 防御側の属性 → 相性 (既定の相性表を上書き・追加する、element.go を参照)
+type ElementRules struct {
+	Order      []string                             `json:"order"`      // 属性を切り替える順番
+	SwitchCost int                                  `json:"switchCost"` // 属性の切り替えで消費するMP
+	Matchups   map[string]map[string]ElementMatchup `json:"matchups"`
+}
+
+// パワーアップの設定
+// 試合中 IntervalMs (± JitterMs) ごとにパワーアップが出現し、WindowMs の間 "claim" アクションで取得できる
+// 出現の予定はシードから決まるので、Seed を指定すると同じ試合を再現できる (0 の場合は試合ごとにランダム)
+// Schedule を指定した場合はランダムな予定の代わりに使う
+type PowerUpRules struct {
+	Seed       uint64         `json:"seed"`
+	IntervalMs int64          `json:"intervalMs"` // 0 の場合は出現しない (既定、パワーアップはルール設定で有効にする)
+	JitterMs   int64          `json:"jitterMs"`
+	WindowMs   int64          `json:"windowMs"`
+	Kinds      []string       `json:"kinds"` // 出現する種類 (空の場合は全ての種類)
+	Schedule   []PowerUpSpawn `json:"schedule"`
+
+	HealAmount      int     `json:"healAmount"` // heal: HPの回復量
+	MPAmount        int     `json:"mpAmount"`   // mp_surge: MPの回復量
+	ShieldMs        int64   `json:"shieldMs"`   // shield: 受けるダメージを ShieldReduction の割合だけ減らす
+	ShieldReduction float64 `json:"shieldReduction"`
+	BoostMs         int64   `json:"boostMs"` // damage_boost: 与えるダメージが BoostMultiplier 倍
+	BoostMultiplier float64 `json:"boostMultiplier"`
+}
+
+// PowerUpSpawn 試合開始から AtMs 後に Kind のパワーアップを出現させる
+type PowerUpSpawn struct {
+	AtMs int64  `json:"atMs"`
+	Kind string `json:"kind"`
+}
+
+// アリーナの特殊ルールの選び方
+// Modifiers を指定した場合は毎試合それを使い、指定しない場合は RandomCount 個をランダムに選ぶ
+type ArenaRules struct {
+	Modifiers   []string `json:"modifiers"`
+	RandomCount int      `json:"randomCount"`
+}
+
+// ロビーの設定
+// 両方のスロットが埋まったら準備確認を行い、ReadyCheckMs 以内に全員が準備完了しなければ取り消す
+// PickPhaseMs が 0 より大きい場合は、準備確認の後にクラスを選ぶ時間を設ける
+type LobbyRules struct {
+	ReadyCheckMs     int64 `json:"readyCheckMs"`
+	PickPhaseMs      int64 `json:"pickPhaseMs"`
+	CountdownSeconds int   `json:"countdownSeconds"`
+}
+
+// ガードブレイクの設定
+// DFを使い切ると StunMs の間行動できず、受けるダメージが DamageMultiplier 倍になる
+// 回復するとDFが RecoveryDF まで戻る
+type GuardBreakRules struct {
+	StunMs           int64   `json:"stunMs"`
+	DamageMultiplier float64 `json:"damageMultiplier"`
+	RecoveryDF       int     `json:"recoveryDf"`
+}
+
+// 溜め攻撃の設定
+// 溜めている間はMPを消費し、離した時に溜めた時間に応じて最大 MaxMultiplier 倍のダメージを与える
+type ChargeRules struct {
+	MinHoldMs        int64   `json:"minHoldMs"` // これより短い溜めは通常の攻撃と同じ
+	MaxHoldMs        int64   `json:"maxHoldMs"`
+	MaxMultiplier    float64 `json:"maxMultiplier"`
+	MPDrainPerSecond float64 `json:"mpDrainPerSecond"`
+}
+
+// 入力の強さによる効果の倍率
+type PowerRules struct {
+	Damage     PowerCurve `json:"damage"`     // 攻撃のダメージ
+	Block      PowerCurve `json:"block"`      // 防御で防ぐダメージの割合
+	Collection PowerCurve `json:"collection"` // MPの回復量
+}
+
+// PowerCurve 強さ p (0〜1) に対する倍率 min + (max - min) * p^exponent
+type PowerCurve struct {
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Exponent float64 `json:"exponent"`
+}
+
+// ジェスチャー認識の設定
+type GestureRules struct {
+	// "threshold" (しきい値) か "dtw" (テンプレートとのDTW距離)
+	Recognizer string `json:"recognizer"`
+	// この確からしさ未満の認識結果は入力として扱わない
+	MinConfidence float64 `json:"minConfidence"`
+
+	// 動作の区切り: 加速度 (1g からのずれ) か角速度がしきい値を超えたら開始し、
+	// QuietMs の間しきい値を下回ったら終了とする
+	StartAccelG   float64 `json:"startAccelG"`
+	StartGyroDps  float64 `json:"startGyroDps"`
+	QuietMs       int64   `json:"quietMs"`
+	MinDurationMs int64   `json:"minDurationMs"`
+	MaxDurationMs int64   `json:"maxDurationMs"`
+
+	// threshold: 加速度のピークで攻撃、角速度のピークで防御、長く振り続けると溜め (collection)
+	AttackAccelG        float64 `json:"attackAccelG"`
+	DefendGyroDps       float64 `json:"defendGyroDps"`
+	CollectionMinMs     int64   `json:"collectionMinMs"`
+	CollectionMinShakes int     `json:"collectionMinShakes"`
+
+	// dtw: テンプレートのJSONファイル ([{"action": "attack", "samples": [...]}, ...])
+	TemplatesFile string `json:"templatesFile"`
+	// この距離を超える場合はどのテンプレートにも一致しない
+	MaxDistance float64 `json:"maxDistance"`
+}
+
+// 入力のレート制限と不正検知の設定
+type AntiCheatRules struct {
+	// トークンバケット (1秒あたりの入力数とバースト)
+	DeviceRate  float64 `json:"deviceRate"`
+	DeviceBurst float64 `json:"deviceBurst"`
+	IPRate      float64 `json:"ipRate"`
+	IPBurst     float64 `json:"ipBurst"`
+
+	// 相手の攻撃からこの時間より早い防御は人間には不可能とみなす (遅延を補正した時刻で比べる)
+	MinReactionMs int64 `json:"minReactionMs"`
+	// 直近 RegularWindow 回の行動間隔の標準偏差がこの値未満なら機械的な入力とみなす
+	RegularJitterMs float64 `json:"regularJitterMs"`
+	RegularWindow   int     `json:"regularWindow"`
+	// 1秒あたりの行動数の上限
+	MaxActionsPerSecond int `json:"maxActionsPerSecond"`
+
+	// 検知回数がこの値に達したらレートを ThrottleFactor 倍に下げる / 入力を停止する
+	ThrottleAfter  int     `json:"throttleAfter"`
+	SuspendAfter   int     `json:"suspendAfter"`
+	ThrottleFactor float64 `json:"throttleFactor"`
+}
+
+var defaultRules = Rules{
+	LagToleranceMs:       120,
+	MaxLagCompensationMs: 300,
+	ParryWindowMs:        150,
+	ParryCounterRatio:    0.5,
+	LateDefenseReduction: 0.5,
+	Gesture: GestureRules{
+		Recognizer:          "threshold",
+		MinConfidence:       0.6,
+		StartAccelG:         0.4,
+		StartGyroDps:        120,
+		QuietMs:             80,
+		MinDurationMs:       80,
+		MaxDurationMs:       1500,
+		AttackAccelG:        1.5,
+		DefendGyroDps:       250,
+		CollectionMinMs:     600,
+		CollectionMinShakes: 4,
+		MaxDistance:         1.5,
+	},
+	AntiCheat: AntiCheatRules{
+		DeviceRate:          10,
+		DeviceBurst:         20,
+		IPRate:              30,
+		IPBurst:             60,
+		MinReactionMs:       150,
+		RegularJitterMs:     3,
+		RegularWindow:       10,
+		MaxActionsPerSecond: 8,
+		ThrottleAfter:       3,
+		SuspendAfter:        10,
+		ThrottleFactor:      0.25,
+	},
+	// 強さを送らないデバイスは最大の強さとして扱うので、max は 1 にしておくと従来と同じ効果になる
+	Power: PowerRules{
+		Damage:     PowerCurve{Min: 0.5, Max: 1, Exponent: 1},
+		Block:      PowerCurve{Min: 0.5, Max: 1, Exponent: 1},
+		Collection: PowerCurve{Min: 0.5, Max: 1, Exponent: 1},
+	},
+	Charge: ChargeRules{
+		MinHoldMs:        200,
+		MaxHoldMs:        2000,
+		MaxMultiplier:    2.5,
+		MPDrainPerSecond: 15,
+	},
+	GuardBreak: GuardBreakRules{
+		StunMs:           2000,
+		DamageMultiplier: 1.5,
+		RecoveryDF:       30,
+	},
+	Lobby: LobbyRules{
+		ReadyCheckMs:     15000,
+		CountdownSeconds: 3,
+	},
+	Stocks: StockRules{
+		Count:          1,
+		RespawnMs:      3000,
+		InvulnerableMs: 2000,
+	},
+	Elements: ElementRules{
+		Order:      []string{"fire", "water", "wind"},
+		SwitchCost: 5,
+	},
+	PowerUps: PowerUpRules{
+		IntervalMs:      0,
+		JitterMs:        5000,
+		WindowMs:        3000,
+		HealAmount:      20,
+		MPAmount:        50,
+		ShieldMs:        5000,
+		ShieldReduction: 0.5,
+		BoostMs:         5000,
+		BoostMultiplier: 1.5,
+	},
+}
+
+var rules = loadRules()
+
+func loadRules() Rules {
+	r := defaultRules
+
+	path := os.Getenv("RULES_CONFIG")
+	if path == "" {
+		return r
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Failed to read rules config %s: %v", path, err)
+		return r
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		log.Printf("Failed to parse rules config %s: %v", path, err)
+		return defaultRules
+	}
+	// クラスは項目ごとに既定のクラスに重ねる
+	classes, err := loadClassOverrides(data)
+	if err != nil {
+		log.Printf("Invalid classes in rules config %s: %v", path, err)
+		return defaultRules
+	}
+	r.Classes = classes
+
+	log.Printf("Loaded rules config from %s", path)
+	return r
+}
+
+// GetRules 現在のルール設定を取得
+func GetRules() Rules {
+	return rules
+}
+
+func (r Rules) lagTolerance() time.Duration {
+	return time.Duration(r.LagToleranceMs) * time.Millisecond
+}
+
+func (r Rules) parryWindow() time.Duration {
+	return time.Duration(r.ParryWindowMs) * time.Millisecond
+}
+
+func (r Rules) maxLagCompensation() time.Duration {
+	return time.Duration(r.MaxLagCompensationMs) * time.Millisecond
+}
+
+// 強さ (0〜1) に対する倍率
+func (c PowerCurve) apply(power float64) float64 {
+	return c.Min + (c.Max-c.Min)*math.Pow(power, c.Exponent)
+}
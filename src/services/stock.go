@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+var ErrRespawning = errors.New("respawning")
+
+// プレイヤーのストックの数 (ストック制でない場合は 1)
+func stockCount() int {
+	return max(1, rules.Stocks.Count)
+}
+
+// HPが0になった (ストックが残っていれば復活を待ち、残っていなければ次の入力で勝敗が決まる)
+func knockOut(player *Player, by *Player) {
+	if player.HP > 0 || player.State == "respawning" {
+		return
+	}
+
+	player.Stocks = max(0, player.Stocks-1)
+	interruptCharge(player)
+	emitGameEvent("knockout", player, by, player.Stocks)
+	if player.Stocks == 0 {
+		return
+	}
+
+	player.State = "respawning"
+	player.Action = "none"
+	player.lastHit = nil
+	respawnAt := time.Now().Add(time.Duration(rules.Stocks.RespawnMs) * time.Millisecond)
+	player.respawnAt = respawnAt
+	log.Printf("Player %s was knocked out (%d stocks left)", player.ID, player.Stocks)
+
+	time.AfterFunc(time.Until(respawnAt), func() {
+		mu.Lock()
+		defer mu.Unlock()
+		respawn(player, respawnAt)
+	})
+}
+
+// ステータスを戻して復活し、しばらくの間は攻撃を受けない
+// (試合が終わった場合や別の復活待ちが始まっている場合は何もしない)
+func respawn(player *Player, respawnAt time.Time) {
+	if player.State != "respawning" || !player.respawnAt.Equal(respawnAt) || GameOver || lobbyPhase() != LobbyFighting {
+		return
+	}
+
+	applyClass(player, player.Class)
+	resetCharge(player)
+	player.State = "fighting"
+	player.respawnAt = time.Time{}
+	player.invulnerableUntil = time.Now().Add(time.Duration(rules.Stocks.InvulnerableMs) * time.Millisecond)
+	log.Printf("Player %s respawned", player.ID)
+	emitGameEvent("respawn", player, nil, player.Stocks)
+	updateGameState()
+}
+
+// HPが0で復活を待っていない (ストックを使い切った)
+func (p *Player) knockedOut() bool {
+	return p.HP == 0 && p.State != "respawning"
+}
+
+// 復活後の無敵時間中か
+func (p *Player) invulnerable() bool {
+	return time.Now().Before(p.invulnerableUntil)
+}
+
+// 復活までの残り時間 (ミリ秒)
+func (p *Player) respawnMs() int64 {
+	if p.State != "respawning" {
+		return 0
+	}
+	return max(0, time.Until(p.respawnAt).Milliseconds())
+}
+
+// 無敵時間の残り (ミリ秒)
+func (p *Player) invulnerableMs() int64 {
+	return max(0, time.Until(p.invulnerableUntil).Milliseconds())
+}
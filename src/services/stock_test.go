@@ -0,0 +1,83 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKnockOut(t *testing.T) {
+	tests := []struct {
+		name       string
+		stocks     int
+		hp         int
+		state      string
+		wantStocks int
+		wantState  string
+	}{
+		{"still has HP", 2, 10, "fighting", 2, "fighting"},
+		{"respawns with stocks left", 2, 0, "fighting", 1, "respawning"},
+		{"last stock", 1, 0, "fighting", 0, "fighting"},
+		{"already respawning", 1, 0, "respawning", 1, "respawning"},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := newTestPlayer("player1")
+			player.Stocks = tt.stocks
+			player.HP = tt.hp
+			player.State = tt.state
+
+			knockOut(player, nil)
+
+			if player.Stocks != tt.wantStocks || player.State != tt.wantState {
+				t.Errorf("stocks, state = %d, %s, want %d, %s", player.Stocks, player.State, tt.wantStocks, tt.wantState)
+			}
+			if knockedOut := tt.hp == 0 && tt.wantStocks == 0; player.knockedOut() != knockedOut {
+				t.Errorf("knockedOut() = %v, want %v", player.knockedOut(), knockedOut)
+			}
+		})
+	}
+}
+
+func TestRespawn(t *testing.T) {
+	respawnAt := time.Now()
+
+	tests := []struct {
+		name      string
+		phase     string
+		respawnAt time.Time
+		gameOver  bool
+		wantState string
+	}{
+		{"respawns", LobbyFighting, respawnAt, false, "fighting"},
+		{"newer knockout", LobbyFighting, respawnAt.Add(time.Second), false, "respawning"},
+		{"game over", LobbyFighting, respawnAt, true, "respawning"},
+		{"match ended", LobbyOpen, respawnAt, false, "respawning"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player, _ := setupTestMatch(t)
+
+			mu.Lock()
+			defer mu.Unlock()
+			lobby.phase = tt.phase
+			player.State = "respawning"
+			player.HP = 0
+			player.respawnAt = tt.respawnAt
+			GameOver = tt.gameOver
+
+			respawn(player, respawnAt)
+
+			if player.State != tt.wantState {
+				t.Fatalf("state = %s, want %s", player.State, tt.wantState)
+			}
+			if tt.wantState == "fighting" && (player.HP != player.class.HP || !player.invulnerable()) {
+				t.Errorf("HP = %d, invulnerable = %v, want %d, true", player.HP, player.invulnerable(), player.class.HP)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"encoding/json"
 	"log"
+	"md2s/dto"
 	"md2s/models"
 	"sync"
 	"time"
@@ -12,38 +13,93 @@ import (
 
 // プレイヤー情報
 type Player struct {
-	ID     string
-	HP     int
-	MP     int
-	DF     int
-	Action string  // 現在の行動 ("attack", "defend", etc.)
+	ID      string
+	UserID  string // スロットに参加したユーザー (ユーザーなしの場合は空)
+	Class   string // キャラクタークラス
+	Element string // 属性 (属性なしの場合は空)
+	HP      int
+	MP      int
+	DF      int
+	Action  string // 現在の行動 ("attack", "defend", etc.)
 	// 準備中か戦闘中かなどの状態
-	State string   // 現在の状態 ("noReady","ready", etc.)
+	State string // 現在の状態 ("noReady","ready", etc.)
 	Time  int
-	Conn   *websocket.Conn
+	Conn  *websocket.Conn
+
+	// 補正後の行動時刻
+	ActionAt time.Time
+	// 行動のジェスチャー認識の確からしさ (0〜1)
+	Confidence float64
+	// 行動の強さ (0〜1)
+	Power float64
+	// 溜め攻撃の進み具合 (0〜100)
+	Charge int
+	// 残りのストック
+	Stocks int
+
+	// 溜め攻撃の状態 (溜めていない場合は chargeStartedAt がゼロ値)
+	chargeStartedAt time.Time // 補正後の溜め開始時刻
+	chargeDrainedAt time.Time // 最後にMPを消費した時刻
+	chargeDrain     float64   // 消費しきれていないMP
+	chargeBase      int       // 溜め開始時のMPによるダメージ
+
+	// ガードブレイクが終わる時刻
+	stunUntil time.Time
+	// パワーアップの効果が終わる時刻
+	shieldUntil time.Time
+	boostUntil  time.Time
+	// 復活する時刻と、復活後の無敵時間が終わる時刻
+	respawnAt         time.Time
+	invulnerableUntil time.Time
+
+	// クラスの設定 (ステータスの上限と行動のコスト)
+	class ClassRules
+	// 直前に受けた攻撃 (ラグ補正用)
+	lastHit *hitRecord
 }
 
+// 受けた攻撃の記録
+type hitRecord struct {
+	attacker   *Player
+	hpLost     int
+	mpSpent    int
+	matchup    ElementMatchup // 攻撃の属性の相性
+	at         time.Time      // 補正後の攻撃時刻
+	receivedAt time.Time
+}
 
 // デバイス情報
 type Device struct {
 	ID   string
 	Conn *websocket.Conn
-}
 
+	writeMu sync.Mutex
+}
 
+// WriteJSON デバイスにメッセージを送信 (複数のgoroutineから呼び出せる)
+func (d *Device) WriteJSON(v any) error {
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+	return d.Conn.WriteJSON(v)
+}
 
 var (
-	devices   = map[string]*Device{} // デバイス情報を管理
-	players   = map[string]*Player{} // プレイヤー情報を管理
-	mu        sync.Mutex             // 同時アクセスを制御
+	devices = map[string]*Device{} // デバイス情報を管理
+	players = map[string]*Player{} // プレイヤー情報を管理
+	mu      sync.Mutex             // 同時アクセスを制御
 )
 
 // デバイスを登録
-func RegisterDevice(id string, conn *websocket.Conn) {
+func RegisterDevice(id string, conn *websocket.Conn) *Device {
 	mu.Lock()
 	defer mu.Unlock()
-	devices[id] = &Device{ID: id, Conn: conn}
+	device := &Device{ID: id, Conn: conn}
+	devices[id] = device
 	log.Printf("Device %s connected", id)
+	if _, paired := playerSlotByDevice(id); paired {
+		touchDevice(id)
+	}
+	return device
 }
 
 // デバイスの登録解除
@@ -55,181 +111,54 @@ func UnregisterDevice(id string) {
 		delete(devices, id)
 		log.Printf("Device %s disconnected", id)
 	}
+	ClearIMUSamples(id)
 }
 
 // プレイヤーを登録
-func RegisterPlayer(id string, conn *websocket.Conn) {
+// userID を指定した場合はユーザーのロードアウトのクラスを適用する
+func RegisterPlayer(id, userID string, conn *websocket.Conn) {
+	class := loadoutClass(userID)
+
 	mu.Lock()
 	defer mu.Unlock()
 	// playerの初期値を設定
-    players[id] = &Player{ID: id, HP: 100, MP: 100, DF: 100, Action: "none",State: "noReady",Time: 3, Conn: conn}
-	log.Printf("Player %s connected", id)
+	player := &Player{ID: id, UserID: userID, Action: "none", State: "noReady", Time: 3, Conn: conn, Stocks: stockCount()}
+	applyClass(player, class)
+	players[id] = player
+	log.Printf("Player %s connected as %s", id, player.Class)
 
 	GameOver = false
+	lobbyPlayerJoined(player)
 
 	// 状態をブロードキャスト
 	broadcastGameState()
 }
 
-// デバイスからの入力を処理
-func ProcessInputFromDevice(deviceID string, message []byte) {
-	mu.Lock()
-	defer mu.Unlock()
-
-	// 入力データを解析
-	var input struct {
-		Action string `json:"action"`
-		State string `json:"state"`
-	}
+// ProcessInputFromDevice WebSocketで受け取ったデバイスの入力を処理
+// HTTP と同じ形式 (dto.DeviceInput) で、timestamp を送ると時刻同期の結果でラグ補正する
+func ProcessInputFromDevice(deviceID string, message []byte) error {
+	var input dto.DeviceInput
 	if err := json.Unmarshal(message, &input); err != nil {
 		log.Printf("Invalid input from device %s: %v", deviceID, err)
-		return
-	}
-
-	// デバイスIDに基づいてプレイヤーを判定
-	var attackingPlayer *Player
-	var targetPlayer *Player
-	if deviceID == "1" {
-		attackingPlayer = players["player1"]
-		targetPlayer = players["player2"]
-	} else if deviceID == "2" {
-		attackingPlayer = players["player2"]
-		targetPlayer = players["player1"]
-	}
-
-	if attackingPlayer == nil || targetPlayer == nil {
-		log.Printf("Invalid device ID: %s", deviceID)
-		return
-	}
-
-	// stateを更新
-	attackingPlayer.State = input.State
-
-	// 準備中の場合、actionを無視する
-
-	if attackingPlayer.State == "noReady" {
-		return
-	}
-
-	// 準備が完了した場合かつ相手も準備が完了している場合、戦闘を開始actionを処理
-	if attackingPlayer.State == "ready" && targetPlayer.State == "ready" {
-
-
-		// 3秒カウントダウン
-		// 3秒後に攻撃処理を実行
-		attackingPlayer.Time = 3
-		targetPlayer.Time = 3
-
-		// ゲーム状態をブロードキャスト
-		broadcastGameState()
-
-		// カウントダウン処理
-		for attackingPlayer.Time > 0 {
-			attackingPlayer.Time--
-			targetPlayer.Time--
-			broadcastGameState()
-			time.Sleep(1 * time.Second)
-		}
-
-
-		// 3秒後にstateを戦闘中に変更
-		attackingPlayer.State = "fighting"
-		targetPlayer.State = "fighting"
-
-		// ゲーム状態をブロードキャスト
-		broadcastGameState()
-
-
-
-
-
-
-	// プレイヤーの行動を更新
-	attackingPlayer.Action = input.Action
-
-	// ゲームロジック: 攻撃処理
-	if input.Action == "attack" {
-		// 相手が防御している場合、攻撃は無効
-		if targetPlayer.Action == "defend" {
-			log.Printf("Player %s's attack was blocked by Player %s's defense!", attackingPlayer.ID, targetPlayer.ID)
-		} else {
-			// 防御していない場合、ダメージを与える
-			damage := attackingPlayer.MP / 10 // MPの10%をダメージとして与える
-			targetPlayer.HP -= damage
-			attackingPlayer.MP -= 20
-			if targetPlayer.HP < 0 {
-				targetPlayer.HP = 0
-			}
-		}
+		return err
 	}
-
-    // ゲームロジック: 防御処理
-    if input.Action == "defend" {
-        attackingPlayer.DF -= 10
-        if attackingPlayer.DF < 0 {
-            attackingPlayer.DF = 0
-        }
-    }
-
-    // ゲームロジック: MP回復
-    if input.Action == "collection" {
-        attackingPlayer.MP += 20
-        if attackingPlayer.MP > 100 {
-            attackingPlayer.MP = 100
-        }
-    }
-
-
-
-
-	// ゲーム終了判定
-	if targetPlayer.HP == 0 {
-		log.Printf("Player %s wins!", attackingPlayer.ID)
-				attackingPlayer.State = "noReady"
-		targetPlayer.State = "noReady"
-
-		// プレイヤーのHPとMP,DFをリセット
-		attackingPlayer.HP = 100
-		targetPlayer.HP = 100
-		attackingPlayer.MP = 100
-		targetPlayer.MP = 100
-		attackingPlayer.DF = 100
-		targetPlayer.DF = 100
-
-
-	}
-
-
-
-	// 状態をブロードキャスト
-	broadcastGameState()
-}
-
+	// デバイスIDは接続のものを使う
+	input.DeviceID = deviceID
+	return HttpProcessInputFromDevice(input)
 }
 
-
 // ゲーム状態を全プレイヤーに送信
 func broadcastGameState() {
-	gameState := models.GameState{
-		Player1HP:   players["player1"].HP,
-		Player1MP:   players["player1"].MP,
-		Player1DF:   players["player1"].DF,
-		Player1Action: players["player1"].Action,
-		Player1State: players["player1"].State,
-		Player2HP:   players["player2"].HP,
-		Player2MP:   players["player2"].MP,
-		Player2DF:   players["player2"].DF,
-		Player2Action: players["player2"].Action,
-		Player2State: players["player2"].State,
-	}
+	gameState := buildGameState()
 
 	for _, player := range players {
 		if player.Conn != nil {
-			err := player.Conn.WriteJSON(gameState)
+			err := writeGameMessage(player.Conn, models.GameMessage{State: &gameState})
 			if err != nil {
 				log.Printf("Error sending game state to player %s: %v", player.ID, err)
 			}
 		}
 	}
-}
 
+	notifyGameStateListeners(gameState)
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"md2s/models"
+	"os"
+)
+
+var (
+	ErrUserUnauthenticated = errors.New("user authentication required")
+	ErrUserTokenDisabled   = errors.New("user tokens are not configured")
+)
+
+// UserToken ユーザーとして操作するためのBearerトークン (USER_TOKEN_SECRET から導出する)
+// ユーザーを確認したサービスが管理者として発行し、クライアントに渡す
+func UserToken(userID string) (string, error) {
+	if _, err := models.StringToUUID(userID); err != nil {
+		return "", ErrInvalidUser
+	}
+	secret := os.Getenv("USER_TOKEN_SECRET")
+	if secret == "" {
+		return "", ErrUserTokenDisabled
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("user:" + userID))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyUserToken ユーザーのBearerトークンを検証する
+// USER_TOKEN_SECRET が未設定の場合は全て拒否する
+func VerifyUserToken(userID, token string) error {
+	expected, err := UserToken(userID)
+	if err != nil || token == "" || !hmac.Equal([]byte(expected), []byte(token)) {
+		return ErrUserUnauthenticated
+	}
+	return nil
+}
+
+// ClaimPlayerUser 参加中のプレイヤーにユーザーを紐付けて、ロードアウトのクラスを適用する
+// ユーザーは VerifyUserToken で確認してから渡す (準備確認を始める前のみ)
+func ClaimPlayerUser(playerID, userID string) error {
+	class := loadoutClass(userID)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	player := players[playerID]
+	if player == nil {
+		return ErrSlotEmpty
+	}
+	switch lobbyPhase() {
+	case LobbyWaiting, LobbyOpen:
+	default:
+		return ErrLobbyPhase
+	}
+	player.UserID = userID
+	applyClass(player, class)
+	log.Printf("Player %s claimed by user %s as %s", playerID, userID, player.Class)
+	updateGameState()
+	return nil
+}